@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/certs"
+	"github.com/costap/tunnelv2/internal/pkg/server/auth"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	certsDir   = "cert"
+	certsCN    string
+	certsOrg   string
+	certsDays  = 365
+	caDays     = 3650
+	certsSANs  []string
+	certsForce bool
+)
+
+// certsCmd represents the certs command
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Manage the certificates of servers and clients",
+}
+
+var certsInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a certificate authority",
+	Args:  cobra.NoArgs,
+	RunE:  certsInitRun,
+}
+
+var certsIssueCmd = &cobra.Command{
+	Use:   "issue (server|client) [name]",
+	Short: "Issue a server or client certificate signed by the certificate authority",
+	Long: `Issue a certificate signed by the certificate authority in the certificates directory.
+The certificate and key are written to <name>-cert.pem and <name>-key.pem, name defaults to the certificate type.`,
+	Args:      cobra.RangeArgs(1, 2),
+	ValidArgs: []string{"server", "client"},
+	RunE:      certsIssueRun,
+}
+
+var certsInspectCmd = &cobra.Command{
+	Use:   "inspect <file>...",
+	Short: "Print the fingerprint, names and validity of certificates",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  certsInspectRun,
+}
+
+func init() {
+	rootCmd.AddCommand(certsCmd)
+	certsCmd.AddCommand(certsInitCmd, certsIssueCmd, certsInspectCmd)
+
+	certsCmd.PersistentFlags().StringVar(&certsDir, "dir", certsDir, "certificates directory")
+
+	for _, c := range []*cobra.Command{certsInitCmd, certsIssueCmd} {
+		c.Flags().StringVar(&certsCN, "cn", "", "subject common name (default is the CA or certificate name)")
+		c.Flags().StringVar(&certsOrg, "org", "", "subject organization")
+		c.Flags().BoolVar(&certsForce, "force", false, "overwrite existing files")
+	}
+	certsInitCmd.Flags().IntVar(&caDays, "days", caDays, "validity in days")
+	certsIssueCmd.Flags().IntVar(&certsDays, "days", certsDays, "validity in days")
+	certsIssueCmd.Flags().StringSliceVar(&certsSANs, "san", nil, "subject alternative names: DNS names, IP addresses, emails or URIs")
+}
+
+// checkWritable refuses to overwrite existing files unless forced
+func checkWritable(paths ...string) error {
+	if certsForce {
+		return nil
+	}
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", p)
+		}
+	}
+	return nil
+}
+
+func certsInitRun(cmd *cobra.Command, args []string) error {
+	certPath, keyPath := filepath.Join(certsDir, "ca-cert.pem"), filepath.Join(certsDir, "ca-key.pem")
+	if err := checkWritable(certPath, keyPath); err != nil {
+		return err
+	}
+	cn := certsCN
+	if cn == "" {
+		cn = "tunnelv2 CA"
+	}
+	ca, err := certs.NewCA(certs.Request{CommonName: cn, Organization: certsOrg, Validity: time.Duration(caDays) * 24 * time.Hour})
+	if err != nil {
+		return fmt.Errorf("cannot create CA: %w", err)
+	}
+	if err := os.MkdirAll(certsDir, 0755); err != nil {
+		return err
+	}
+	if err := ca.Save(certPath, keyPath); err != nil {
+		return fmt.Errorf("cannot save CA: %w", err)
+	}
+	printCertificate(certPath, ca.Cert)
+	return nil
+}
+
+func certsIssueRun(cmd *cobra.Command, args []string) error {
+	var usage certs.Usage
+	switch args[0] {
+	case "server":
+		usage = certs.ServerUsage
+	case "client":
+		usage = certs.ClientUsage
+	default:
+		return fmt.Errorf("unknown certificate type %q, expected server or client", args[0])
+	}
+	name := args[0]
+	if len(args) > 1 {
+		name = args[1]
+	}
+	// the name is part of the file names in the certificates directory
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid certificate name %q, it may not contain path separators or ..", name)
+	}
+	certPath, keyPath := filepath.Join(certsDir, name+"-cert.pem"), filepath.Join(certsDir, name+"-key.pem")
+	if err := checkWritable(certPath, keyPath); err != nil {
+		return err
+	}
+
+	ca, err := certs.LoadCA(filepath.Join(certsDir, "ca-cert.pem"), filepath.Join(certsDir, "ca-key.pem"))
+	if err != nil {
+		return fmt.Errorf("cannot load CA, create one with certs init: %w", err)
+	}
+	cn := certsCN
+	if cn == "" {
+		cn = name
+	}
+	kp, err := ca.Issue(certs.Request{CommonName: cn, Organization: certsOrg, SANs: certsSANs, Validity: time.Duration(certsDays) * 24 * time.Hour}, usage)
+	if err != nil {
+		return fmt.Errorf("cannot issue certificate: %w", err)
+	}
+	if err := kp.Save(certPath, keyPath); err != nil {
+		return fmt.Errorf("cannot save certificate: %w", err)
+	}
+	printCertificate(certPath, kp.Cert)
+	return nil
+}
+
+func certsInspectRun(cmd *cobra.Command, args []string) error {
+	for _, path := range args {
+		cs, err := certs.LoadCertificates(path)
+		if err != nil {
+			return err
+		}
+		for _, c := range cs {
+			printCertificate(path, c)
+		}
+	}
+	return nil
+}
+
+func printCertificate(path string, c *x509.Certificate) {
+	fmt.Printf("%s\n", path)
+	fmt.Printf("  Subject:     %s\n", c.Subject)
+	fmt.Printf("  Issuer:      %s\n", c.Issuer)
+	if names := auth.CertificateNames(c); len(names) > 0 {
+		fmt.Printf("  Names:       %s\n", strings.Join(names, ", "))
+	}
+	for _, ip := range c.IPAddresses {
+		fmt.Printf("  IP address:  %s\n", ip)
+	}
+	fmt.Printf("  CA:          %v\n", c.IsCA)
+	fmt.Printf("  Serial:      %x\n", c.SerialNumber)
+	fmt.Printf("  Not before:  %s\n", c.NotBefore.Format(time.RFC3339))
+	expiry := fmt.Sprintf("expires in %d days", int(time.Until(c.NotAfter).Hours()/24))
+	if time.Now().After(c.NotAfter) {
+		expiry = "expired"
+	}
+	fmt.Printf("  Not after:   %s (%s)\n", c.NotAfter.Format(time.RFC3339), expiry)
+	fmt.Printf("  SHA-256:     %s\n", auth.Fingerprint(c))
+}
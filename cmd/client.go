@@ -3,22 +3,61 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/allow"
 	"github.com/costap/tunnelv2/internal/pkg/client"
+	"github.com/costap/tunnelv2/internal/pkg/compress"
+	"github.com/costap/tunnelv2/internal/pkg/flow"
+	"github.com/costap/tunnelv2/internal/pkg/heartbeat"
 	tunnelv1 "github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"net"
 	"os"
-	"time"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
 	serverAddress = "localhost:9000"
 	targetAddress = "jsa-admin.thewindgod.com:80"
+	tunnelName    = "default"
+	clientTunnels map[string]string
+	weight        = 1
+	clientWindow  = flow.DefaultWindow
+	// clientPriorities are the scheduling classes of tunnels
+	clientPriorities map[string]int
+	// clientStreamPerConnection asks the server to carry each connection on its own stream
+	clientStreamPerConnection bool
+	// clientStreams are the tunnel streams opened in parallel over clientConnections connections to the server
+	clientStreams     = 1
+	clientConnections = 1
+	backoff           = client.DefaultBackoff
+	clientHeartbeat   = heartbeat.Default
+	// clientCompression are the algorithms compressing the data sent on each tunnel
+	clientCompression          map[string]string
+	clientCompressionThreshold = compress.DefaultThreshold
+	// clientProxyProtocol are the versions of the PROXY protocol header sent to the target of each tunnel
+	clientProxyProtocol map[string]string
+	token               string
+	tokenFile           string
+	// clientInsecure dials the server in plaintext, for local development only
+	clientInsecure bool
+	// clientAPI is the version of the tunnel API spoken to the server
+	clientAPI = "v1"
+	// clientLocal is the address of the local listener whose connections the server forwards to clientRemote
+	clientLocal  string
+	clientRemote string
+	// clientSOCKS are the tunnels whose connections dial the destinations SOCKS or HTTP proxy peers ask the server for,
+	// restricted to clientSOCKSAllow
+	clientSOCKS      []string
+	clientSOCKSAllow []string
 )
 
 // clientCmd represents the client command
@@ -33,47 +72,164 @@ func init() {
 
 	clientCmd.Flags().StringVarP(&serverAddress, "server", "s", serverAddress, "server address")
 	clientCmd.Flags().StringVarP(&targetAddress, "target", "t", targetAddress, "server address")
+	clientCmd.Flags().StringVarP(&tunnelName, "name", "n", tunnelName, "name of the tunnel to register for target")
+	clientCmd.Flags().StringToStringVar(&clientTunnels, "tunnel", clientTunnels, "tunnels to register as name=target pairs, overrides name and target")
+	clientCmd.Flags().IntVar(&weight, "weight", weight, "relative share of connections when the server balances randomly")
+	clientCmd.Flags().IntVar(&clientWindow, "window", clientWindow, "bytes the server may send on each connection before the target reads them")
+	clientCmd.Flags().StringToIntVar(&clientPriorities, "priority", clientPriorities, "scheduling class of tunnels as name=class pairs, higher classes are sent first (default 0)")
+	clientCmd.Flags().BoolVar(&clientStreamPerConnection, "stream-per-connection", clientStreamPerConnection, "carry each connection on its own gRPC stream if the server supports it")
+	clientCmd.Flags().IntVar(&clientStreams, "streams", clientStreams, "tunnel streams opened in parallel, the server spreads connections across them")
+	clientCmd.Flags().IntVar(&clientConnections, "connections", clientConnections, "TCP connections to the server the streams are spread over")
+	clientCmd.Flags().DurationVar(&backoff.Initial, "backoff-initial", backoff.Initial, "delay before reconnecting after the tunnel breaks")
+	clientCmd.Flags().DurationVar(&backoff.Max, "backoff-max", backoff.Max, "maximum delay between reconnection attempts")
+	clientCmd.Flags().DurationVar(&clientHeartbeat.Interval, "heartbeat-interval", clientHeartbeat.Interval, "interval between pings checking the server is alive, disabled if 0")
+	clientCmd.Flags().IntVar(&clientHeartbeat.Misses, "heartbeat-misses", clientHeartbeat.Misses, "unanswered pings in a row after which the tunnel is re-established")
+	clientCmd.Flags().StringToStringVar(&clientCompression, "compression", clientCompression, "compression of the data sent to the server as tunnel=algorithm pairs, algorithms are none, gzip or zstd (default none)")
+	clientCmd.Flags().IntVar(&clientCompressionThreshold, "compression-threshold", clientCompressionThreshold, "size in bytes below which data is sent uncompressed")
+	clientCmd.Flags().StringToStringVar(&clientProxyProtocol, "proxy-protocol", clientProxyProtocol, "PROXY protocol header announcing the public peer to the target as tunnel=version pairs, versions are v1 or v2")
+	clientCmd.Flags().StringVar(&clientLocal, "local", clientLocal, "local address to listen to, the server dials the remote destination for each connection, like ssh -L")
+	clientCmd.Flags().StringVar(&clientRemote, "remote", clientRemote, "destination in the network of the server the connections of the local address are forwarded to, as host:port")
+	clientCmd.Flags().StringSliceVar(&clientSOCKS, "socks-tunnel", clientSOCKS, "tunnels to register whose connections dial the destination SOCKS or HTTP proxy peers ask the server for, like ssh -R with dynamic forwarding")
+	clientCmd.Flags().StringSliceVar(&clientSOCKSAllow, "socks-allow", clientSOCKSAllow, "destinations SOCKS or HTTP proxy peers may reach as host:port entries, hosts may be names, *.suffix wildcards, IPs or CIDRs and ports numbers, ranges or *")
+	clientCmd.Flags().StringVar(&token, "token", token, "token authenticating the client")
+	clientCmd.Flags().StringVar(&tokenFile, "token-file", tokenFile, "file containing the token authenticating the client")
+	clientCmd.Flags().String("ca-cert", "cert/ca-cert.pem", "CA bundle verifying the server certificate, as a PEM file or inline PEM, the system roots if empty")
+	clientCmd.Flags().String("cert", "", "client certificate chain for mutual TLS, as a PEM file or inline PEM")
+	clientCmd.Flags().String("key", "", "client private key for mutual TLS, as a PEM file or inline PEM, read from the certificate bundle if empty")
+	clientCmd.Flags().StringVar(&clientAPI, "api", clientAPI, "version of the tunnel API spoken to the server, v1 or v2")
+	clientCmd.Flags().BoolVar(&clientInsecure, "insecure", clientInsecure, "dial the server in plaintext without TLS, for local development only")
+	cobra.CheckErr(viper.BindPFlag(caCertKey, clientCmd.Flags().Lookup("ca-cert")))
+	cobra.CheckErr(viper.BindPFlag(clientCertKey, clientCmd.Flags().Lookup("cert")))
+	cobra.CheckErr(viper.BindPFlag(clientKeyKey, clientCmd.Flags().Lookup("key")))
 }
 
 func clientRun(cmd *cobra.Command, args []string) error {
 	log := newZapLogger(debug)
-	tlsCredentials, err := loadClientTLSCredentials()
-	if err != nil {
-		log.Fatal("cannot load TLS credentials: ", zap.Error(err))
+	var opts []grpc.DialOption
+	if clientInsecure {
+		log.Warn("Dialing server in plaintext, do not use outside of local development")
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		tlsCredentials, err := loadClientTLSCredentials()
+		if err != nil {
+			log.Fatal("cannot load TLS credentials: ", zap.Error(err))
+		}
+		opts = append(opts, grpc.WithTransportCredentials(tlsCredentials))
+	}
+	if tokenFile != "" {
+		b, err := os.ReadFile(tokenFile)
+		if err != nil {
+			log.Fatal("cannot read token file: ", zap.Error(err))
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(client.NewTokenCredentials(token, !clientInsecure)))
 	}
 
-	cc1, err := grpc.Dial(serverAddress, grpc.WithTransportCredentials(tlsCredentials))
-	defer cc1.Close()
+	compression, err := parseCompression(clientCompression)
+	if err != nil {
+		return err
+	}
+	proxy, err := parseProxyProtocol(clientProxyProtocol)
 	if err != nil {
-		log.Fatal("cannot dial server: ", zap.Error(err))
+		return err
+	}
+	if clientLocal != "" && clientRemote == "" {
+		return fmt.Errorf("--local requires a --remote destination")
+	}
+	var destinations map[string]*allow.List
+	if len(clientSOCKS) > 0 {
+		if len(clientSOCKSAllow) == 0 {
+			return fmt.Errorf("--socks-tunnel requires --socks-allow destinations")
+		}
+		list, err := allow.Parse(clientSOCKSAllow)
+		if err != nil {
+			return err
+		}
+		destinations = make(map[string]*allow.List, len(clientSOCKS))
+		for _, name := range clientSOCKS {
+			destinations[name] = list
+		}
+	}
+	newClient := tunnelv1.NewTunnelServiceClient
+	switch clientAPI {
+	case "v1":
+	case "v2":
+		newClient = client.NewClientV2
+	default:
+		return fmt.Errorf("unknown API version %q", clientAPI)
+	}
+	var tcs []tunnelv1.TunnelServiceClient
+	for i := 0; i < clientConnections || i == 0; i++ {
+		cc, err := grpc.Dial(serverAddress, opts...)
+		if err != nil {
+			log.Fatal("cannot dial server: ", zap.Error(err))
+		}
+		defer cc.Close()
+		tcs = append(tcs, newClient(cc))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	// Run until the process is shutdown.
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	tc := tunnelv1.NewTunnelServiceClient(cc1)
-	r := client.NewRouter(log, tc, targetAddress)
-	if err := r.Start(ctx); err != nil {
-		return fmt.Errorf("cannot start router: %w", err)
+	// a client forwarding a local address or SOCKS peers only serves the tunnels it is given
+	if len(clientTunnels) == 0 && clientLocal == "" && len(clientSOCKS) == 0 {
+		clientTunnels = map[string]string{tunnelName: targetAddress}
+	}
+
+	r := client.NewRouter(log, tcs[0], clientTunnels, weight,
+		client.WithClients(tcs[1:]...),
+		client.WithStreams(clientStreams),
+		client.WithBackoff(backoff),
+		client.WithWindow(clientWindow),
+		client.WithPriorities(clientPriorities),
+		client.WithStreamPerConnection(clientStreamPerConnection),
+		client.WithHeartbeat(clientHeartbeat),
+		client.WithCompression(compression),
+		client.WithCompressionThreshold(clientCompressionThreshold),
+		client.WithProxyProtocol(proxy),
+		client.WithDestinations(destinations),
+		client.WithVersion(GetVersion(false)))
+	if clientLocal != "" {
+		l, err := net.Listen("tcp", clientLocal)
+		if err != nil {
+			return fmt.Errorf("cannot listen to local address: %w", err)
+		}
+		log.Info("forwarding local address", zap.String("local", l.Addr().String()), zap.String("remote", clientRemote))
+		go func() {
+			if err := r.Forward(ctx, l, clientRemote); err != nil {
+				log.Error("cannot accept local connections", zap.Error(err))
+			}
+		}()
+	}
+	if err := r.Run(ctx); err != nil {
+		return fmt.Errorf("cannot run router: %w", err)
 	}
 	return nil
 }
 
 func loadClientTLSCredentials() (credentials.TransportCredentials, error) {
-	// Load certificate of the CA who signed server's certificate
-	pemServerCA, err := os.ReadFile("cert/ca-cert.pem")
-	if err != nil {
-		return nil, err
-	}
+	// Create the credentials and return it
+	config := &tls.Config{}
 
-	certPool := x509.NewCertPool()
-	if !certPool.AppendCertsFromPEM(pemServerCA) {
-		return nil, fmt.Errorf("failed to add server CA's certificate")
+	// Load certificate of the CA who signed server's certificate
+	if viper.GetString(caCertKey) != "" {
+		certPool, err := loadCertPool(caCertKey)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = certPool
 	}
 
-	// Create the credentials and return it
-	config := &tls.Config{
-		RootCAs: certPool,
+	// Present a client certificate in mutual TLS mode
+	if viper.GetString(clientCertKey) != "" {
+		clientCert, err := loadKeyPair(clientCertKey, clientKeyKey)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{clientCert}
 	}
 
 	return credentials.NewTLS(config), nil
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/compress"
+	"github.com/costap/tunnelv2/internal/pkg/proxyproto"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"os"
@@ -31,3 +34,29 @@ func newZapLogger(debug bool) *zap.Logger {
 		}))
 	return zap.New(core)
 }
+
+// parseCompression parses the compression algorithms of tunnels given as name=algorithm pairs
+func parseCompression(algorithms map[string]string) (map[string]compress.Algorithm, error) {
+	parsed := make(map[string]compress.Algorithm, len(algorithms))
+	for name, algorithm := range algorithms {
+		a, err := compress.ParseAlgorithm(algorithm)
+		if err != nil {
+			return nil, fmt.Errorf("tunnel %s: %w", name, err)
+		}
+		parsed[name] = a
+	}
+	return parsed, nil
+}
+
+// parseProxyProtocol parses the PROXY protocol versions of tunnels given as name=version pairs
+func parseProxyProtocol(versions map[string]string) (map[string]proxyproto.Version, error) {
+	parsed := make(map[string]proxyproto.Version, len(versions))
+	for name, version := range versions {
+		v, err := proxyproto.ParseVersion(version)
+		if err != nil {
+			return nil, fmt.Errorf("tunnel %s: %w", name, err)
+		}
+		parsed[name] = v
+	}
+	return parsed, nil
+}
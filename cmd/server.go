@@ -3,26 +3,77 @@ package cmd
 import (
 	"crypto/tls"
 	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/allow"
+	"github.com/costap/tunnelv2/internal/pkg/compress"
+	"github.com/costap/tunnelv2/internal/pkg/flow"
+	"github.com/costap/tunnelv2/internal/pkg/heartbeat"
 	tunnelv1 "github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	tunnelv2 "github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v2"
+	"github.com/costap/tunnelv2/internal/pkg/server/auth"
 	"github.com/costap/tunnelv2/internal/pkg/server/tcp"
 	tunnel2 "github.com/costap/tunnelv2/internal/pkg/server/tunnel"
+	"github.com/costap/tunnelv2/internal/pkg/server/udp"
 	"github.com/jzelinskie/cobrautil"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"html/template"
 	"net"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
 	tcpPort    = 8080
 	grpcPort   = 9000
 	listenHost = ""
+	tunnels    map[string]int
+	balancer   = string(tunnel2.RoundRobin)
+	// serverInsecure serves plaintext gRPC, for local development only
+	serverInsecure bool
+	statsInterval  time.Duration
+	serverWindow   = flow.DefaultWindow
+	// serverPriorities are the scheduling classes of tunnels
+	serverPriorities map[string]int
+	// serverStreamPerConnection carries connections on their own stream for clients asking for it
+	serverStreamPerConnection bool
+	// serverGracePeriod holds the connections of a client whose stream broke for it to resume its session
+	serverGracePeriod time.Duration
+	serverHeartbeat   = heartbeat.Default
+	// serverCompression are the algorithms compressing the data sent on each tunnel
+	serverCompression          map[string]string
+	serverCompressionThreshold = compress.DefaultThreshold
+	// serverSNI are the tunnels whose public peers start with a TLS client hello
+	serverSNI []string
+	// udpTunnels are the named tunnels of UDP sessions, udpIdleTimeout closes the sessions without packets
+	udpTunnels     map[string]int
+	udpIdleTimeout = udp.DefaultIdleTimeout
+	// serverDialAllow are the destinations clients may have the server dial for their local forwards
+	serverDialAllow []string
+	// serverSOCKS are the tunnels whose public peers speak SOCKS5, the client dials the destination they ask for
+	serverSOCKS []string
+	// serverHTTPProxy are the tunnels whose public peers speak to an HTTP proxy, the client dials the destination
+	// they ask for
+	serverHTTPProxy []string
+	// vhostPort is the public port whose HTTP requests are routed to the tunnel named after their Host, disabled if 0
+	vhostPort      int
+	vhostErrorPage string
+)
+
+const (
+	tokensKey         = "tokens"
+	tokenFileKey      = "token-file"
+	clientCAKey       = "client-ca"
+	identitiesKey     = "identities"
+	revokedCertsKey   = "revoked-certs"
+	socksUsersKey     = "socks-users"
+	httpProxyUsersKey = "http-proxy-users"
 )
 
 // serverCmd represents the server command
@@ -39,17 +90,69 @@ func init() {
 
 	serverCmd.Flags().IntVar(&tcpPort, "tcp-port", tcpPort, "public port to listen to")
 	serverCmd.Flags().IntVar(&grpcPort, "grpc-port", grpcPort, "private port to listen to")
+	serverCmd.Flags().StringToIntVar(&tunnels, "tunnel", tunnels, "named tunnels as name=port pairs (default is default=<tcp-port>)")
+	serverCmd.Flags().StringToIntVar(&udpTunnels, "udp-tunnel", udpTunnels, "named tunnels of UDP sessions as name=port pairs, the client dials their target over UDP")
+	serverCmd.Flags().DurationVar(&udpIdleTimeout, "udp-idle-timeout", udpIdleTimeout, "time after which a UDP session without packets in either direction is closed")
+	serverCmd.Flags().DurationVar(&statsInterval, "stats-interval", statsInterval, "interval between logs of the tunnel stats, disabled if 0")
+	serverCmd.Flags().IntVar(&serverWindow, "window", serverWindow, "bytes a client may send on each connection before the public side reads them")
+	serverCmd.Flags().StringToIntVar(&serverPriorities, "priority", serverPriorities, "scheduling class of tunnels as name=class pairs, higher classes are sent first (default 0)")
+	serverCmd.Flags().BoolVar(&serverStreamPerConnection, "stream-per-connection", serverStreamPerConnection, "carry each connection on its own gRPC stream for clients asking for it")
+	serverCmd.Flags().DurationVar(&serverGracePeriod, "grace-period", serverGracePeriod, "time the connections of a client whose stream broke are held for it to resume, closed at once if 0")
+	serverCmd.Flags().DurationVar(&serverHeartbeat.Interval, "heartbeat-interval", serverHeartbeat.Interval, "interval between pings measuring the round-trip time to clients, disabled if 0")
+	serverCmd.Flags().IntVar(&serverHeartbeat.Misses, "heartbeat-misses", serverHeartbeat.Misses, "unanswered pings in a row after which a client is disconnected")
+	serverCmd.Flags().StringToStringVar(&serverCompression, "compression", serverCompression, "compression of the data sent to clients as tunnel=algorithm pairs, algorithms are none, gzip or zstd (default none)")
+	serverCmd.Flags().IntVar(&serverCompressionThreshold, "compression-threshold", serverCompressionThreshold, "size in bytes below which data is sent uncompressed")
+	serverCmd.Flags().StringSliceVar(&serverSNI, "sni", serverSNI, "tunnels whose public peers start with a TLS handshake, the server name they ask for is passed to the client")
+	serverCmd.Flags().StringSliceVar(&serverDialAllow, "dial-allow", serverDialAllow, "destinations clients may have the server dial for their local forwards as host:port entries, hosts may be names, *.suffix wildcards, IPs or CIDRs and ports numbers, ranges or * (default none)")
+	serverCmd.Flags().StringSliceVar(&serverSOCKS, "socks", serverSOCKS, "tunnels whose public peers speak SOCKS5, the client dials the destination of their CONNECT requests")
+	serverCmd.Flags().StringToString("socks-user", nil, "usernames and passwords SOCKS peers must authenticate with as user=password pairs (default no authentication)")
+	cobra.CheckErr(viper.BindPFlag(socksUsersKey, serverCmd.Flags().Lookup("socks-user")))
+	serverCmd.Flags().StringSliceVar(&serverHTTPProxy, "http-proxy", serverHTTPProxy, "tunnels whose public peers speak to an HTTP proxy, the client dials the destination of their CONNECT or absolute-URI requests")
+	serverCmd.Flags().StringToString("http-proxy-user", nil, "usernames and passwords HTTP proxy peers must authenticate with as user=password pairs (default no authentication)")
+	cobra.CheckErr(viper.BindPFlag(httpProxyUsersKey, serverCmd.Flags().Lookup("http-proxy-user")))
+	serverCmd.Flags().IntVar(&vhostPort, "vhost-port", vhostPort, "public port whose HTTP requests are routed to the tunnel named after their Host or the wildcard of its parent domain such as *.example.com, disabled if 0")
+	serverCmd.Flags().StringVar(&vhostErrorPage, "vhost-error-page", vhostErrorPage, "HTML template of the page answering vhost requests with 404 or 502, given .Status, .StatusText and .Host")
+	serverCmd.Flags().StringVar(&balancer, "balancer", balancer, "strategy spreading connections across clients of a tunnel: round-robin, least-connections or random")
+	serverCmd.Flags().StringSlice("token", nil, "tokens accepted from clients")
+	serverCmd.Flags().String("token-file", "", "file with the sha256 hashes of the tokens accepted from clients, one per line")
+	cobra.CheckErr(viper.BindPFlag(tokensKey, serverCmd.Flags().Lookup("token")))
+	cobra.CheckErr(viper.BindPFlag(tokenFileKey, serverCmd.Flags().Lookup("token-file")))
+	serverCmd.Flags().String("cert", "cert/server-cert.pem", "server certificate chain, as a PEM file or inline PEM")
+	serverCmd.Flags().String("key", "cert/server-key.pem", "server private key, as a PEM file or inline PEM, read from the certificate bundle if empty")
+	serverCmd.Flags().BoolVar(&serverInsecure, "insecure", serverInsecure, "serve plaintext gRPC without TLS, for local development only")
+	cobra.CheckErr(viper.BindPFlag(serverCertKey, serverCmd.Flags().Lookup("cert")))
+	cobra.CheckErr(viper.BindPFlag(serverKeyKey, serverCmd.Flags().Lookup("key")))
+	serverCmd.Flags().String("client-ca", "", "CA bundle verifying client certificates, as a PEM file or inline PEM, enables mutual TLS")
+	serverCmd.Flags().StringSlice("revoked-cert", nil, "sha256 fingerprints of revoked client certificates")
+	cobra.CheckErr(viper.BindPFlag(clientCAKey, serverCmd.Flags().Lookup("client-ca")))
+	cobra.CheckErr(viper.BindPFlag(revokedCertsKey, serverCmd.Flags().Lookup("revoked-cert")))
 }
 
 type server struct {
 	logger        *zap.Logger
 	tunnelService *tunnel2.Service
-	controller    *tunnel2.Controller
+	// permissions are shared by the authenticator of certificates and the tunnel service
+	permissions auth.Permissions
+
+	grpcServer   *grpc.Server
+	listeners    []tcpListener
+	udpListeners []udpListener
+}
 
-	grpcServer *grpc.Server
+// tcpListener is a public listener for a named tunnel
+type tcpListener struct {
+	address    string
+	controller tcp.Handler
 	tcpServer  *tcp.Server
 }
 
+// udpListener is a public UDP socket for a named tunnel
+type udpListener struct {
+	address    string
+	controller *tunnel2.DatagramController
+	udpServer  *udp.Server
+}
+
 func (s *server) startGRPC(address string, wg *sync.WaitGroup) {
 	defer func() {
 		wg.Done()
@@ -62,17 +165,28 @@ func (s *server) startGRPC(address string, wg *sync.WaitGroup) {
 		return
 	}
 
-	tlsCredentials, err := loadServerTLSCredentials()
+	var opts []grpc.ServerOption
+	if serverInsecure {
+		s.logger.Warn("Serving plaintext gRPC, do not use outside of local development")
+	} else {
+		tlsCredentials, err := loadServerTLSCredentials()
+		if err != nil {
+			s.logger.Fatal("cannot load TLS credentials: ", zap.Error(err))
+		}
+		opts = append(opts, grpc.Creds(tlsCredentials))
+	}
+	authenticator, err := loadAuthenticator(s.permissions)
 	if err != nil {
-		s.logger.Fatal("cannot load TLS credentials: ", zap.Error(err))
+		s.logger.Fatal("cannot load authenticator: ", zap.Error(err))
 	}
-
-	s.grpcServer = grpc.NewServer(
-		grpc.Creds(tlsCredentials),
-		//grpc.UnaryInterceptor(interceptor.Unary()),
-		//grpc.StreamInterceptor(interceptor.Stream()),
-	)
+	if authenticator != nil {
+		opts = append(opts, grpc.StreamInterceptor(auth.StreamInterceptor(s.logger, authenticator)))
+	} else {
+		s.logger.Warn("No tokens or client CA configured, clients are not authenticated")
+	}
+	s.grpcServer = grpc.NewServer(opts...)
 	tunnelv1.RegisterTunnelServiceServer(s.grpcServer, s.tunnelService)
+	tunnelv2.RegisterTunnelServiceServer(s.grpcServer, tunnel2.NewServiceV2(s.tunnelService))
 
 	s.logger.Info("Starting gRPC server", zap.String("address", listener.Addr().String()))
 	if err := s.grpcServer.Serve(listener); err != nil {
@@ -81,27 +195,44 @@ func (s *server) startGRPC(address string, wg *sync.WaitGroup) {
 	}
 }
 
-func (s *server) startTcp(address string, wg *sync.WaitGroup) {
+func (s *server) startTcp(l tcpListener, wg *sync.WaitGroup) {
 	defer func() {
 		wg.Done()
 		s.logger.Warn("Stopped HTTP server")
 	}()
 
-	listener, err := net.Listen("tcp", address)
+	listener, err := net.Listen("tcp", l.address)
 	if err != nil {
 		s.logger.Fatal("Failed to create TCP server", zap.Error(err))
 	}
-	s.tcpServer = tcp.NewServer()
 	s.logger.Info("Server is running...", zap.String("address", listener.Addr().String()))
-	if err := s.tcpServer.Serve(listener, s.controller); err != nil {
+	if err := l.tcpServer.Serve(listener, l.controller); err != nil {
 		s.logger.Fatal("Failed to start TCP server", zap.Error(err))
 	}
 }
 
-func (s *server) run(tcpAddr, grpcAddr string) {
+func (s *server) startUdp(l udpListener, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	pc, err := net.ListenPacket("udp", l.address)
+	if err != nil {
+		s.logger.Fatal("Failed to create UDP server", zap.Error(err))
+	}
+	s.logger.Info("UDP server is running...", zap.String("address", pc.LocalAddr().String()))
+	if err := l.udpServer.Serve(pc, l.controller); err != nil {
+		s.logger.Fatal("Failed to start UDP server", zap.Error(err))
+	}
+}
+
+func (s *server) run(grpcAddr string) {
 	var wg sync.WaitGroup
-	wg.Add(2)
-	go s.startTcp(tcpAddr, &wg)
+	wg.Add(len(s.listeners) + len(s.udpListeners) + 1)
+	for _, l := range s.listeners {
+		go s.startTcp(l, &wg)
+	}
+	for _, l := range s.udpListeners {
+		go s.startUdp(l, &wg)
+	}
 	go s.startGRPC(grpcAddr, &wg)
 	wg.Wait()
 }
@@ -109,31 +240,186 @@ func (s *server) run(tcpAddr, grpcAddr string) {
 func (s *server) stop() {
 	s.grpcServer.Stop()
 	s.logger.Info("GRPC server stopped")
-	s.logger.Info("TCP server stopped", zap.Error(s.tcpServer.Close()))
+	for _, l := range s.listeners {
+		s.logger.Info("TCP server stopped", zap.String("address", l.address), zap.Error(l.tcpServer.Close()))
+	}
+	for _, l := range s.udpListeners {
+		s.logger.Info("UDP server stopped", zap.String("address", l.address), zap.Error(l.udpServer.Close()))
+	}
 }
 
 func serveRun(cmd *cobra.Command, args []string) {
 	logger := newZapLogger(cobrautil.MustGetBool(cmd, "debug"))
 	logger.Info("Server is starting...", zap.String("Version", GetVersion(false)))
 
-	ts := tunnel2.NewService(logger)
-	s := server{logger: logger, tunnelService: ts, controller: tunnel2.NewController(logger, ts)}
+	strategy, err := tunnel2.ParseStrategy(balancer)
+	if err != nil {
+		logger.Fatal("Invalid balancer", zap.Error(err))
+	}
+	compression, err := parseCompression(serverCompression)
+	if err != nil {
+		logger.Fatal("Invalid compression", zap.Error(err))
+	}
+	var destinations *allow.List
+	if len(serverDialAllow) > 0 {
+		if destinations, err = allow.Parse(serverDialAllow); err != nil {
+			logger.Fatal("Invalid dial allowlist", zap.Error(err))
+		}
+	}
+	permissions, err := loadPermissions(logger)
+	if err != nil {
+		logger.Fatal("Invalid permissions", zap.Error(err))
+	}
+	ts := tunnel2.NewService(logger,
+		tunnel2.WithBalancer(strategy),
+		tunnel2.WithPermissions(permissions),
+		tunnel2.WithWindow(serverWindow),
+		tunnel2.WithPriorities(serverPriorities),
+		tunnel2.WithStreamPerConnection(serverStreamPerConnection),
+		tunnel2.WithGracePeriod(serverGracePeriod),
+		tunnel2.WithHeartbeat(serverHeartbeat),
+		tunnel2.WithCompression(compression),
+		tunnel2.WithCompressionThreshold(serverCompressionThreshold),
+		tunnel2.WithDial(destinations),
+		tunnel2.WithVersion(GetVersion(false)))
+	s := server{logger: logger, tunnelService: ts, permissions: permissions}
 
 	tcpPort := cobrautil.MustGetInt(cmd, "tcp-port")
 	grpcPort := cobrautil.MustGetInt(cmd, "grpc-port")
 
-	go s.run(fmt.Sprintf(":%v", tcpPort), fmt.Sprintf(":%v", grpcPort))
+	if len(tunnels) == 0 {
+		tunnels = map[string]int{"default": tcpPort}
+	}
+	sni := make(map[string]bool)
+	for _, name := range serverSNI {
+		sni[name] = true
+	}
+	socks := make(map[string]bool)
+	for _, name := range serverSOCKS {
+		socks[name] = true
+	}
+	socksUsers := viper.GetStringMapString(socksUsersKey)
+	httpProxy := make(map[string]bool)
+	for _, name := range serverHTTPProxy {
+		httpProxy[name] = true
+	}
+	httpProxyUsers := viper.GetStringMapString(httpProxyUsersKey)
+	for name, port := range tunnels {
+		var opts []tunnel2.ControllerOption
+		if socks[name] {
+			opts = append(opts, tunnel2.WithSOCKS(socksUsers))
+		} else if httpProxy[name] {
+			opts = append(opts, tunnel2.WithHTTPProxy(httpProxyUsers))
+		} else if sni[name] {
+			opts = append(opts, tunnel2.WithServerName(tunnel2.DefaultServerNameTimeout))
+		}
+		s.listeners = append(s.listeners, tcpListener{
+			address:    fmt.Sprintf(":%v", port),
+			controller: tunnel2.NewController(logger.With(zap.String("tunnel", name)), ts, name, opts...),
+			tcpServer:  tcp.NewServer(),
+		})
+	}
+
+	if vhostPort != 0 {
+		var opts []tunnel2.VhostOption
+		if vhostErrorPage != "" {
+			page, err := template.ParseFiles(vhostErrorPage)
+			if err != nil {
+				logger.Fatal("Invalid vhost error page", zap.Error(err))
+			}
+			opts = append(opts, tunnel2.WithErrorPage(page))
+		}
+		s.listeners = append(s.listeners, tcpListener{
+			address:    fmt.Sprintf(":%v", vhostPort),
+			controller: tunnel2.NewVhostController(logger.With(zap.String("listener", "vhost")), ts, opts...),
+			tcpServer:  tcp.NewServer(),
+		})
+	}
+
+	for name, port := range udpTunnels {
+		s.udpListeners = append(s.udpListeners, udpListener{
+			address:    fmt.Sprintf(":%v", port),
+			controller: tunnel2.NewDatagramController(logger.With(zap.String("tunnel", name)), ts, name),
+			udpServer:  udp.NewServer(udpIdleTimeout),
+		})
+	}
+
+	go s.run(fmt.Sprintf(":%v", grpcPort))
 	defer s.stop()
 
+	if statsInterval > 0 {
+		go func() {
+			for range time.Tick(statsInterval) {
+				ts.LogStats()
+			}
+		}()
+	}
+
 	// Wait for the process to be shutdown.
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	<-sigs
 }
 
+// loadPermissions returns the tunnels each client may register and the destinations it may dial. The identities
+// config key lists certificate names, or token names after the token: prefix. Token clients may register any tunnel
+// unless they are listed too.
+func loadPermissions(logger *zap.Logger) (auth.Permissions, error) {
+	permissions := auth.NewPermissions(viper.GetStringMapStringSlice(identitiesKey))
+	if err := permissions.Validate(); err != nil {
+		return nil, err
+	}
+	if len(permissions) == 0 {
+		return permissions, nil
+	}
+	var tokens []string
+	if static := viper.GetStringSlice(tokensKey); len(static) > 0 {
+		tokens = append(tokens, auth.NewStaticTokens(static).Identities()...)
+	}
+	if path := viper.GetString(tokenFileKey); path != "" {
+		a, err := auth.LoadHashedTokens(path)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, a.Identities()...)
+	}
+	var unlisted []string
+	for _, id := range tokens {
+		if !permissions.Lists(id) {
+			unlisted = append(unlisted, id)
+		}
+	}
+	if len(unlisted) > 0 {
+		logger.Info("Token clients missing from identities may register any tunnel", zap.Strings("identities", unlisted))
+	}
+	return permissions.Unrestricted(tokens), nil
+}
+
+// loadAuthenticator returns the authenticator for the configured tokens and client CA, nil if there are none
+func loadAuthenticator(permissions auth.Permissions) (auth.Authenticator, error) {
+	var authenticators []auth.Authenticator
+	if viper.GetString(clientCAKey) != "" {
+		authenticators = append(authenticators, auth.NewCertificateAuthenticator(permissions, viper.GetStringSlice(revokedCertsKey)))
+	}
+	if tokens := viper.GetStringSlice(tokensKey); len(tokens) > 0 {
+		authenticators = append(authenticators, auth.NewStaticTokens(tokens))
+	}
+	if path := viper.GetString(tokenFileKey); path != "" {
+		a, err := auth.LoadHashedTokens(path)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if len(authenticators) == 0 {
+		return nil, nil
+	}
+	return auth.AnyOf(authenticators...), nil
+}
+
 func loadServerTLSCredentials() (credentials.TransportCredentials, error) {
 	// Load server's certificate and private key
-	serverCert, err := tls.LoadX509KeyPair("cert/server-cert.pem", "cert/server-key.pem")
+	serverCert, err := loadKeyPair(serverCertKey, serverKeyKey)
 	if err != nil {
 		return nil, err
 	}
@@ -144,5 +430,15 @@ func loadServerTLSCredentials() (credentials.TransportCredentials, error) {
 		ClientAuth:   tls.NoClientCert,
 	}
 
+	// Verify client certificates against the client CA bundle in mutual TLS mode
+	if viper.GetString(clientCAKey) != "" {
+		certPool, err := loadCertPool(clientCAKey)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = certPool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
 	return credentials.NewTLS(config), nil
 }
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/spf13/viper"
+	"os"
+	"strings"
+)
+
+const (
+	serverCertKey = "server-cert"
+	serverKeyKey  = "server-key"
+	caCertKey     = "ca-cert"
+	clientCertKey = "client-cert"
+	clientKeyKey  = "client-key"
+)
+
+// readPEM returns the content of a config key holding either inline PEM or the path of a PEM file
+func readPEM(key string) ([]byte, error) {
+	value := viper.GetString(key)
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+	b, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return b, nil
+}
+
+// loadKeyPair loads a certificate chain and its private key, the key is read from the
+// certificate PEM bundle when keyKey is not set
+func loadKeyPair(certKey, keyKey string) (tls.Certificate, error) {
+	certPEM, err := readPEM(certKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM := certPEM
+	if viper.GetString(keyKey) != "" {
+		if keyPEM, err = readPEM(keyKey); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("%s: %w", certKey, err)
+	}
+	return cert, nil
+}
+
+// loadCertPool loads a bundle of one or more CA certificates
+func loadCertPool(key string) (*x509.CertPool, error) {
+	b, err := readPEM(key)
+	if err != nil {
+		return nil, err
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("%s: no CA certificate found", key)
+	}
+	return certPool, nil
+}
@@ -0,0 +1,170 @@
+package allow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// ErrDenied is returned for destinations the list does not allow
+var ErrDenied = errors.New("destination not allowed")
+
+// List is an allowlist of destinations, given as host:port entries. Hosts are names, names with a leading wildcard
+// label such as *.internal, IP addresses or CIDR ranges. Ports are numbers, ranges such as 8000-8999, or *.
+// The empty list allows nothing.
+type List struct {
+	rules []rule
+}
+
+type rule struct {
+	// name is the host name, or its suffix with the leading dot for a wildcard, empty for an IP rule
+	name     string
+	wildcard bool
+	prefix   netip.Prefix
+	// ports is the range of ports allowed, inclusive
+	low, high uint16
+}
+
+// Parse parses the entries of a list
+func Parse(entries []string) (*List, error) {
+	l := &List{}
+	for _, entry := range entries {
+		r, err := parseRule(entry)
+		if err != nil {
+			return nil, fmt.Errorf("allowlist entry %q: %w", entry, err)
+		}
+		l.rules = append(l.rules, r)
+	}
+	return l, nil
+}
+
+func parseRule(entry string) (rule, error) {
+	i := strings.LastIndex(entry, ":")
+	if i < 0 {
+		return rule{}, errors.New("missing port")
+	}
+	host, ports := strings.Trim(entry[:i], "[]"), entry[i+1:]
+	var r rule
+	switch {
+	case ports == "*":
+		r.low, r.high = 0, 65535
+	case strings.Contains(ports, "-"):
+		low, high, _ := strings.Cut(ports, "-")
+		l, err := parsePort(low)
+		if err != nil {
+			return rule{}, err
+		}
+		h, err := parsePort(high)
+		if err != nil {
+			return rule{}, err
+		}
+		if l > h {
+			return rule{}, fmt.Errorf("empty port range %s", ports)
+		}
+		r.low, r.high = l, h
+	default:
+		p, err := parsePort(ports)
+		if err != nil {
+			return rule{}, err
+		}
+		r.low, r.high = p, p
+	}
+	if prefix, err := netip.ParsePrefix(host); err == nil {
+		r.prefix = prefix.Masked()
+		return r, nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		r.prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		return r, nil
+	}
+	if host == "" {
+		return rule{}, errors.New("missing host")
+	}
+	r.name = strings.ToLower(host)
+	if strings.HasPrefix(r.name, "*.") {
+		r.name, r.wildcard = r.name[1:], true
+	}
+	return r, nil
+}
+
+func parsePort(s string) (uint16, error) {
+	p, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return uint16(p), nil
+}
+
+func (r rule) port(p uint16) bool {
+	return r.low <= p && p <= r.high
+}
+
+func (r rule) allowsName(name string, port uint16) bool {
+	if r.name == "" || !r.port(port) {
+		return false
+	}
+	if r.wildcard {
+		return strings.HasSuffix(name, r.name)
+	}
+	return name == r.name
+}
+
+func (r rule) allowsAddr(addr netip.Addr, port uint16) bool {
+	return r.prefix.IsValid() && r.port(port) && r.prefix.Contains(addr)
+}
+
+// AllowsAddr returns whether the list allows an IP address and port
+func (l *List) AllowsAddr(addr netip.Addr, port uint16) bool {
+	addr = addr.Unmap()
+	for _, r := range l.rules {
+		if r.allowsAddr(addr, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow returns the address to dial for destination, given as host:port, or an error wrapping ErrDenied if the list
+// does not allow it. Names that only IP rules could allow are resolved, the address returned is then the allowed IP
+// so that the name is not resolved again to another one when dialed.
+func (l *List) Allow(ctx context.Context, destination string) (string, error) {
+	host, ports, err := net.SplitHostPort(destination)
+	if err != nil {
+		return "", err
+	}
+	port, err := parsePort(ports)
+	if err != nil {
+		return "", err
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if l.AllowsAddr(addr, port) {
+			return destination, nil
+		}
+		return "", fmt.Errorf("%w: %s", ErrDenied, destination)
+	}
+	name := strings.ToLower(strings.TrimSuffix(host, "."))
+	ipRules := false
+	for _, r := range l.rules {
+		if r.allowsName(name, port) {
+			return destination, nil
+		}
+		ipRules = ipRules || r.prefix.IsValid()
+	}
+	if !ipRules {
+		return "", fmt.Errorf("%w: %s", ErrDenied, destination)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		if l.AllowsAddr(addr, port) {
+			return netip.AddrPortFrom(addr.Unmap(), port).String(), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrDenied, destination)
+}
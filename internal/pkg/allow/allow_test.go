@@ -0,0 +1,88 @@
+package allow
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+type allowTest struct {
+	destination string
+	expected    string
+}
+
+func TestList_AllowNames(t *testing.T) {
+	testAllow(t, []string{"db.internal:5432", "*.svc.local:8000-8999"}, []allowTest{
+		{"db.internal:5432", "db.internal:5432"},
+		{"DB.internal.:5432", "DB.internal.:5432"},
+		{"db.internal:5433", ""},
+		{"api.svc.local:8080", "api.svc.local:8080"},
+		{"svc.local:8080", ""},
+		{"api.svc.local:9000", ""},
+		{"10.1.2.3:5432", ""},
+	})
+}
+
+func TestList_AllowAddrs(t *testing.T) {
+	testAllow(t, []string{"10.0.0.0/8:*", "192.0.2.1:22", "[2001:db8::/32]:443"}, []allowTest{
+		{"10.1.2.3:1", "10.1.2.3:1"},
+		{"11.1.2.3:1", ""},
+		{"192.0.2.1:22", "192.0.2.1:22"},
+		{"192.0.2.1:23", ""},
+		{"[::ffff:192.0.2.1]:22", "[::ffff:192.0.2.1]:22"},
+		{"[2001:db8::1]:443", "[2001:db8::1]:443"},
+		{"[2001:db9::1]:443", ""},
+	})
+}
+
+func testAllow(t *testing.T, entries []string, tests []allowTest) {
+	l, err := Parse(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		got, err := l.Allow(context.Background(), tt.destination)
+		if tt.expected == "" {
+			if !errors.Is(err, ErrDenied) {
+				t.Fatalf("%s: expected to be denied, got %q, %v", tt.destination, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.expected {
+			t.Fatalf("%s: expected %s, got %q, %v", tt.destination, tt.expected, got, err)
+		}
+	}
+}
+
+func TestList_AllowResolved(t *testing.T) {
+	l, err := Parse([]string{"127.0.0.0/8:5432"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := l.Allow(context.Background(), "localhost:5432")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr, err := netip.ParseAddrPort(got); err != nil || !addr.Addr().IsLoopback() {
+		t.Fatalf("expected the resolved loopback address, got %q", got)
+	}
+}
+
+func TestList_Empty(t *testing.T) {
+	l, err := Parse(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Allow(context.Background(), "127.0.0.1:80"); !errors.Is(err, ErrDenied) {
+		t.Fatalf("expected the empty list to deny, got %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, entry := range []string{"db.internal", ":80", "db.internal:http", "db.internal:90-80", "db.internal:70000"} {
+		if _, err := Parse([]string{entry}); err == nil {
+			t.Fatalf("expected %q to be refused", entry)
+		}
+	}
+}
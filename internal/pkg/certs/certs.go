@@ -0,0 +1,236 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Usage is what an issued certificate may be used for
+type Usage int
+
+const (
+	ServerUsage Usage = iota
+	ClientUsage
+)
+
+// Request describes a certificate to create
+type Request struct {
+	CommonName   string
+	Organization string
+	// SANs are DNS names, IP addresses, email addresses or URIs
+	SANs     []string
+	Validity time.Duration
+}
+
+// KeyPair is a certificate with its private key
+type KeyPair struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// NewCA creates a self-signed certificate authority
+func NewCA(req Request) (*KeyPair, error) {
+	template, err := newTemplate(req)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	return create(template, nil)
+}
+
+// ErrNotCA is returned when a certificate cannot sign others
+var ErrNotCA = errors.New("not a certificate authority")
+
+// checkCA returns ErrNotCA unless the certificate is a CA whose key may sign certificates
+func checkCA(cert *x509.Certificate) error {
+	if !cert.BasicConstraintsValid || !cert.IsCA {
+		return fmt.Errorf("%w: %q has no CA basic constraints", ErrNotCA, cert.Subject.CommonName)
+	}
+	if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return fmt.Errorf("%w: %q may not sign certificates", ErrNotCA, cert.Subject.CommonName)
+	}
+	return nil
+}
+
+// Issue creates a certificate signed by the CA for a server or a client, valid until the CA expires at most
+func (ca *KeyPair) Issue(req Request, usage Usage) (*KeyPair, error) {
+	if err := checkCA(ca.Cert); err != nil {
+		return nil, err
+	}
+	template, err := newTemplate(req)
+	if err != nil {
+		return nil, err
+	}
+	if template.NotAfter.After(ca.Cert.NotAfter) {
+		template.NotAfter = ca.Cert.NotAfter
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	switch usage {
+	case ServerUsage:
+		if len(template.DNSNames) == 0 && len(template.IPAddresses) == 0 {
+			return nil, errors.New("server certificates need at least one DNS name or IP address")
+		}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	case ClientUsage:
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	return create(template, ca)
+}
+
+func newTemplate(req Request) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: req.CommonName},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(req.Validity),
+	}
+	if req.Organization != "" {
+		template.Subject.Organization = []string{req.Organization}
+	}
+	for _, san := range req.SANs {
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if strings.Contains(san, "://") {
+			u, err := url.Parse(san)
+			if err != nil {
+				return nil, fmt.Errorf("invalid URI %q: %w", san, err)
+			}
+			template.URIs = append(template.URIs, u)
+		} else if strings.Contains(san, "@") {
+			if _, err := mail.ParseAddress(san); err != nil {
+				return nil, fmt.Errorf("invalid email address %q: %w", san, err)
+			}
+			template.EmailAddresses = append(template.EmailAddresses, san)
+		} else {
+			template.DNSNames = append(template.DNSNames, san)
+		}
+	}
+	return template, nil
+}
+
+// create signs the template with the issuer, or self-signs it without one
+func create(template *x509.Certificate, issuer *KeyPair) (*KeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	parent, signer := template, crypto.Signer(key)
+	if issuer != nil {
+		parent, signer = issuer.Cert, issuer.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{Cert: cert, Key: key}, nil
+}
+
+// Save writes the certificate and the private key as PEM files, the key is only readable by the owner
+func (kp *KeyPair) Save(certPath, keyPath string) error {
+	key, err := x509.MarshalPKCS8PrivateKey(kp.Key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: kp.Cert.Raw}), 0644)
+}
+
+// Load reads a certificate and its private key from PEM files
+func Load(certPath, keyPath string) (*KeyPair, error) {
+	certs, err := LoadCertificates(certPath)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM private key found", keyPath)
+	}
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyPath, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported private key", keyPath)
+	}
+	return &KeyPair{Cert: certs[0], Key: signer}, nil
+}
+
+// LoadCA reads a certificate authority and its private key from PEM files, failing unless it may sign certificates
+func LoadCA(certPath, keyPath string) (*KeyPair, error) {
+	kp, err := Load(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCA(kp.Cert); err != nil {
+		return nil, fmt.Errorf("%s: %w", certPath, err)
+	}
+	return kp, nil
+}
+
+// LoadCertificates reads all certificates of a PEM bundle
+func LoadCertificates(path string) ([]*x509.Certificate, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s: no PEM certificate found", path)
+	}
+	return certs, nil
+}
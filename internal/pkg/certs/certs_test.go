@@ -0,0 +1,81 @@
+package certs
+
+import (
+	"crypto/x509"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKeyPair_Issue(t *testing.T) {
+	ca, err := NewCA(Request{CommonName: "test CA", Validity: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	caCert, caKey := filepath.Join(dir, "ca-cert.pem"), filepath.Join(dir, "ca-key.pem")
+	if err := ca.Save(caCert, caKey); err != nil {
+		t.Fatal(err)
+	}
+	ca, err = LoadCA(caCert, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := ca.Issue(Request{CommonName: "server", SANs: []string{"localhost", "127.0.0.1"}, Validity: time.Hour}, ServerUsage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ca.Issue(Request{CommonName: "client", SANs: []string{"spiffe://example/client", "ops@example.com"}, Validity: time.Hour}, ClientUsage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	if _, err := server.Cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: "localhost"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.Cert.URIs) != 1 || len(client.Cert.EmailAddresses) != 1 {
+		t.Fatalf("expected URI and email SANs, got %v and %v", client.Cert.URIs, client.Cert.EmailAddresses)
+	}
+	if _, err := ca.Issue(Request{CommonName: "server", Validity: time.Hour}, ServerUsage); err == nil {
+		t.Fatal("expected error for server certificate without names")
+	}
+}
+
+func TestKeyPair_IssueCA(t *testing.T) {
+	ca, err := NewCA(Request{CommonName: "test CA", Validity: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the certificates expire with the CA
+	long, err := ca.Issue(Request{CommonName: "client", Validity: 24 * time.Hour}, ClientUsage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !long.Cert.NotAfter.Equal(ca.Cert.NotAfter) {
+		t.Fatalf("expected the validity to end with the CA at %v, got %v", ca.Cert.NotAfter, long.Cert.NotAfter)
+	}
+
+	// a leaf certificate cannot issue others
+	leaf, err := ca.Issue(Request{CommonName: "server", SANs: []string{"localhost"}, Validity: time.Hour}, ServerUsage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leaf.Issue(Request{CommonName: "client", Validity: time.Hour}, ClientUsage); !errors.Is(err, ErrNotCA) {
+		t.Fatalf("expected %v, got %v", ErrNotCA, err)
+	}
+	dir := t.TempDir()
+	leafCert, leafKey := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := leaf.Save(leafCert, leafKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCA(leafCert, leafKey); !errors.Is(err, ErrNotCA) {
+		t.Fatalf("expected %v, got %v", ErrNotCA, err)
+	}
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff configures the delay between attempts to re-establish the tunnel stream
+type Backoff struct {
+	// Initial is the delay before the first retry
+	Initial time.Duration
+	// Max caps the delay between retries
+	Max time.Duration
+	// Multiplier grows the delay after each failed attempt
+	Multiplier float64
+	// Jitter randomises the delay by up to this fraction in either direction
+	Jitter float64
+}
+
+// DefaultBackoff is used when no backoff is configured
+var DefaultBackoff = Backoff{Initial: time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.2}
+
+// Delay returns the jittered delay before the given retry attempt, starting at 0
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt))
+	if d > float64(b.Max) || math.IsInf(d, 0) {
+		d = float64(b.Max)
+	}
+	d += d * b.Jitter * (rand.Float64()*2 - 1)
+	return time.Duration(d)
+}
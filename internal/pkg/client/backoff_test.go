@@ -0,0 +1,28 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2, Jitter: 0.5}
+	tt := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{1000, time.Second},
+	}
+	for _, tc := range tt {
+		for i := 0; i < 100; i++ {
+			d := b.Delay(tc.attempt)
+			if d < tc.base/2 || d > tc.base*3/2 {
+				t.Fatalf("attempt %d: expected delay within 50%% of %v, got %v", tc.attempt, tc.base, d)
+			}
+		}
+	}
+}
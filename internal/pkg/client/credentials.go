@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"google.golang.org/grpc/credentials"
+)
+
+// tokenCredentials sends a bearer token with every call
+type tokenCredentials struct {
+	token      string
+	requireTLS bool
+}
+
+// NewTokenCredentials returns credentials authenticating the client with a token,
+// requireTLS refuses to send the token over a plaintext connection
+func NewTokenCredentials(token string, requireTLS bool) credentials.PerRPCCredentials {
+	return tokenCredentials{token: token, requireTLS: requireTLS}
+}
+
+func (c tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
@@ -2,67 +2,219 @@ package client
 
 import (
 	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"github.com/costap/tunnelv2/internal/pkg/proxyproto"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"io"
 	"net"
 	"sync"
+	"time"
 )
 
+// DialTimeout bounds the time to connect to a target
+const DialTimeout = 10 * time.Second
+
+// ConnectionInfo describes the public side of a tunnelled connection, as seen by the server
+type ConnectionInfo struct {
+	// RemoteAddr is the address of the public peer
+	RemoteAddr string
+	// LocalAddr is the address of the server listener that accepted the connection
+	LocalAddr  string
+	AcceptedAt time.Time
+	// ServerName is the name the peer asked for in its TLS client hello, empty unless the server looked for it
+	ServerName string
+}
+
+func (i ConnectionInfo) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("remoteAddress", i.RemoteAddr)
+	enc.AddString("localAddress", i.LocalAddr)
+	enc.AddTime("acceptedAt", i.AcceptedAt)
+	if i.ServerName != "" {
+		enc.AddString("serverName", i.ServerName)
+	}
+	return nil
+}
+
 // ConnectionHandler is a handler for a single connection
 type ConnectionHandler struct {
 	log          *zap.Logger
 	connectionId string
 	target       string
-	in, out      chan []byte
-	done         chan struct{}
-	running      uint32
+	// network dials the target, tcp or udp, whose reads and writes are then single packets
+	network string
+	info    ConnectionInfo
+	// proxy is the version of the PROXY protocol header sent to the target, none if 0
+	proxy          proxyproto.Version
+	in, out        chan []byte
+	done           chan struct{}
+	closeOnce      sync.Once
+	closeWrite     chan struct{}
+	closeWriteOnce sync.Once
+	conn           net.Conn
+	running        uint32
+}
+
+// HandlerOption configures a ConnectionHandler
+type HandlerOption func(*ConnectionHandler)
+
+// WithInfo sets the description of the public side of the connection, added to the logs of the handler
+func WithInfo(info ConnectionInfo) HandlerOption {
+	return func(h *ConnectionHandler) {
+		h.info = info
+		h.log = h.log.With(zap.Object("peer", info))
+	}
+}
+
+// WithProxyHeader sends the target a PROXY protocol header announcing the public peer before any data
+func WithProxyHeader(version proxyproto.Version) HandlerOption {
+	return func(h *ConnectionHandler) {
+		h.proxy = version
+	}
+}
+
+// WithNetwork sets the network the target is dialed on, tcp or udp
+func WithNetwork(network string) HandlerOption {
+	return func(h *ConnectionHandler) {
+		h.network = network
+	}
+}
+
+// WithConn has the handler carry conn, a connection the client accepted, instead of connecting to a target
+func WithConn(conn net.Conn) HandlerOption {
+	return func(h *ConnectionHandler) {
+		h.conn = conn
+	}
 }
 
 // NewConnectionHandler creates a new connection handler
-func NewConnectionHandler(log *zap.Logger, connectionId, target string, in, out chan []byte) *ConnectionHandler {
-	return &ConnectionHandler{log: log, connectionId: connectionId, target: target, in: in, out: out, running: 0}
+func NewConnectionHandler(log *zap.Logger, connectionId, target string, in, out chan []byte, opts ...HandlerOption) *ConnectionHandler {
+	h := &ConnectionHandler{log: log, connectionId: connectionId, target: target, network: "tcp", in: in, out: out, done: make(chan struct{}), closeWrite: make(chan struct{}), running: 0}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Info describes the public side of the connection
+func (h *ConnectionHandler) Info() ConnectionInfo {
+	return h.info
+}
+
+// Open connects to the target, unless the handler carries an accepted connection
+func (h *ConnectionHandler) Open() error {
+	if h.conn != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout(h.network, h.target, DialTimeout)
+	if err != nil {
+		return fmt.Errorf("cannot connect to target: %w", err)
+	}
+	if h.proxy != 0 {
+		if err := h.writeProxyHeader(conn); err != nil {
+			conn.Close()
+			return fmt.Errorf("cannot send PROXY header to target: %w", err)
+		}
+	}
+	h.conn = conn
+	h.log.Debug("connected to target", zap.String("connectionId", h.connectionId), zap.String("target", h.target), zap.String("network", h.network))
+	return nil
+}
+
+// writeProxyHeader announces the public peer to the target, along with the connection id and the server name
+func (h *ConnectionHandler) writeProxyHeader(conn net.Conn) error {
+	tlvs := []proxyproto.TLV{{Type: proxyproto.TypeUniqueID, Value: []byte(h.connectionId)}}
+	if h.info.ServerName != "" {
+		tlvs = append(tlvs, proxyproto.TLV{Type: proxyproto.TypeAuthority, Value: []byte(h.info.ServerName)})
+	}
+	header, err := proxyproto.Header(h.proxy, h.info.RemoteAddr, h.info.LocalAddr, tlvs...)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(header)
+	return err
 }
 
-// Run starts the connection handler
+// Run starts the connection handler, connecting to the target unless already open.
+// out is closed once the target stops sending, Run returns once both directions are closed.
 func (h *ConnectionHandler) Run() error {
 	h.running += 1
 	h.log.Debug("starting connection handler", zap.String("connectionId", h.connectionId))
-	h.done = make(chan struct{})
-	conn, err := net.Dial("tcp", h.target)
-	defer conn.Close()
-	if err != nil {
-		return fmt.Errorf("cannot connect to target: %w", err)
+	if h.conn == nil {
+		if err := h.Open(); err != nil {
+			return err
+		}
 	}
-	h.log.Debug("connected to target")
+	conn := h.conn
+	defer conn.Close()
 	wg := &sync.WaitGroup{}
 	wg.Add(2)
+	readDone := make(chan struct{})
+	size := 1024
+	if h.network == "udp" {
+		// a read returns a single packet, truncated to the buffer
+		size = protocol.MaxDatagramSize
+	}
 	go func() {
+		defer close(readDone)
+		buf := make([]byte, size)
 		for {
-			buf := make([]byte, 1024)
 			n, err := conn.Read(buf)
 			if err == io.EOF {
 				h.log.Debug("connection closed by remote")
 				break
 			}
 			if err != nil {
-				h.log.Error("cannot read from connection", zap.Error(err))
+				select {
+				case <-h.done:
+				default:
+					h.log.Error("cannot read from connection", zap.Error(err))
+					h.Close()
+				}
 				break
 			}
 			h.log.Info("read from connection", zap.String("connectionId", h.connectionId), zap.ByteString("data", buf[:n]))
-			h.out <- buf[:n]
+			select {
+			// the data is copied out of the read buffer at its own size
+			case h.out <- append([]byte(nil), buf[:n]...):
+				continue
+			case <-h.done:
+			}
+			break
 		}
+		close(h.out)
 		wg.Done()
 	}()
 	go func() {
 		for {
 			select {
 			case <-h.done:
+				conn.Close()
+				wg.Done()
+				return
+			case <-h.closeWrite:
+				h.log.Debug("closing write side of connection", zap.String("connectionId", h.connectionId))
+				if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+					if err := cw.CloseWrite(); err != nil {
+						h.log.Error("cannot close write side of connection", zap.Error(err))
+						h.Close()
+						conn.Close()
+					}
+				}
+				// the reader may still be blocked until the handler is closed
+				select {
+				case <-h.done:
+					conn.Close()
+				case <-readDone:
+				}
 				wg.Done()
 				return
 			case data := <-h.in:
 				_, err := conn.Write(data)
 				if err != nil {
 					h.log.Error("cannot write to connection", zap.Error(err))
+					h.Close()
+					conn.Close()
 					wg.Done()
 					return
 				}
@@ -75,6 +227,31 @@ func (h *ConnectionHandler) Run() error {
 	return nil
 }
 
+// Close stops the connection handler, it is safe to call more than once
 func (h *ConnectionHandler) Close() {
-	close(h.done)
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+// CloseWrite shuts down the write side of the target connection once the data written so far is sent.
+// The handler stops when the target closes its side too. It is safe to call more than once.
+func (h *ConnectionHandler) CloseWrite() {
+	h.closeWriteOnce.Do(func() {
+		close(h.closeWrite)
+	})
+}
+
+// Done is closed when the handler is closed
+func (h *ConnectionHandler) Done() <-chan struct{} {
+	return h.done
+}
+
+// Write hands data to the target, dropping it if the handler or its write side is closed
+func (h *ConnectionHandler) Write(data []byte) {
+	select {
+	case h.in <- data:
+	case <-h.done:
+	case <-h.closeWrite:
+	}
 }
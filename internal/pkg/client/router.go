@@ -2,28 +2,941 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/allow"
+	"github.com/costap/tunnelv2/internal/pkg/compress"
+	"github.com/costap/tunnelv2/internal/pkg/flow"
+	"github.com/costap/tunnelv2/internal/pkg/heartbeat"
 	tunnelv1 "github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"github.com/costap/tunnelv2/internal/pkg/proxyproto"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"io"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// State is the connection state of a Router
+type State int
+
+const (
+	StateIdle State = iota
+	StateConnecting
+	StateConnected
+	StateReconnecting
+	StateStopped
+)
+
+func (s State) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateStopped:
+		return "stopped"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// errStreamClosed is reported when the server ends the stream
+var errStreamClosed = errors.New("stream closed by server")
+
+// lingerTimeout bounds the time spent handing the target the data of a connection the server closed
+const lingerTimeout = 5 * time.Second
+
+// dialWait bounds the time the server takes to dial the destination of an accepted connection
+const dialWait = 2 * DialTimeout
+
+// heartbeatPriority sends heartbeats ahead of the connections so that the round-trip time leaves out their queueing
+const heartbeatPriority = math.MaxInt32
+
+// Scheduler queues of the requests of no connection, apart so that each keeps its own priority class
+const (
+	heartbeatQueue = "#heartbeat"
+	dialQueue      = "#dial"
+)
+
+// connection is a target connection with its flow control state
+type connection struct {
+	*ConnectionHandler
+	// input queues the data of the server until the target accepts it, written is closed once it is handed over
+	input   *flow.Buffer
+	written chan struct{}
+	// window is the credit granted by the server to send it data
+	window *flow.Window
+	// priority is the scheduling class of the tunnel
+	priority int
+	// network is the transport of the connection, the data of a UDP session is sent as whole packets
+	network tunnelv1.Network
+	// ob is the outbox of the stream carrying the connection, dedicated if it is the connection's own stream
+	ob        *outbox
+	dedicated bool
+	// replay keeps the data sent until the server consumed it, nil unless the session can be resumed.
+	// sent is the offset of the end of the data sent, mu orders the requests queued with them.
+	replay *flow.Replay
+	sent   int64
+	mu     sync.Mutex
+	// encoder compresses the data sent to the server
+	encoder compress.Encoder
+}
+
+// outbox schedules the requests sent on a stream
+type outbox = flow.Scheduler[*tunnelv1.TunnelRequest]
+
+// registration holds the settings the server accepted for a Tunnel stream
+type registration struct {
+	// window is the credit the server grants each connection
+	window int
+	// streamPerConnection carries each connection on its own Connect stream
+	streamPerConnection bool
+	// session identifies the stream on the server, resumed if it is the session of the previous stream
+	session string
+	resumed bool
+	// grace is how long the server holds the connections of the stream once it breaks
+	grace time.Duration
+	// capabilities are those agreed on with the server
+	capabilities protocol.Capabilities
+}
+
 type Router struct {
-	log         *zap.Logger
-	client      tunnelv1.TunnelServiceClient
-	target      string
-	connections map[string]*ConnectionHandler
+	log *zap.Logger
+	// version of the client binary, sent to the server
+	version string
+	// clients are the connections to the server, the first one also carries the Connect streams
+	clients    []tunnelv1.TunnelServiceClient
+	streams    int
+	targets    map[string]string
+	weight     int
+	window     int
+	priorities map[string]int
+	backoff    Backoff
+	heartbeat  heartbeat.Config
+	// compression is the algorithm compressing the data sent on each tunnel, for data of at least threshold bytes
+	compression map[string]compress.Algorithm
+	threshold   int
+	// proxy is the version of the PROXY protocol header sent to the target of each tunnel
+	proxy map[string]proxyproto.Version
+	// destinations restrict the destinations the server may have the connections of each tunnel dial
+	destinations map[string]*allow.List
+	// streamPerConnection asks the server to carry each connection on its own stream
+	streamPerConnection bool
+
+	// pool, sessions and outboxes outlive the streams, so that the connections carry on when the sessions are resumed
+	pool     string
+	sessions []string
+	outboxes []*outbox
+	// expiry closes the connections of broken streams that were not resumed in time
+	expiry *time.Timer
+
+	mu          sync.Mutex
+	state       State
+	rtt         time.Duration
+	connections map[string]*connection
+	// dials are the accepted connections waiting for the server to dial their destination, by dial id.
+	// dialing is set if the server agreed to dial destinations.
+	dials   map[uint64]net.Conn
+	dialID  uint64
+	dialing bool
 }
 
-func NewRouter(log *zap.Logger, client tunnelv1.TunnelServiceClient, target string) *Router {
-	return &Router{log: log, client: client, target: target, connections: make(map[string]*ConnectionHandler)}
+// RouterOption configures a Router
+type RouterOption func(*Router)
+
+// WithBackoff sets the delays between attempts to re-establish the tunnel stream
+func WithBackoff(b Backoff) RouterOption {
+	return func(r *Router) {
+		r.backoff = b
+	}
 }
 
-func (r *Router) Start(ctx context.Context) error {
-	stream, err := r.client.Tunnel(ctx)
+// WithVersion sets the version of the client binary sent to the server
+func WithVersion(version string) RouterOption {
+	return func(r *Router) {
+		r.version = version
+	}
+}
+
+// WithHeartbeat sets the interval of the pings sent to the server and the number of unanswered pings after which
+// the server is considered dead and the stream re-established
+func WithHeartbeat(config heartbeat.Config) RouterOption {
+	return func(r *Router) {
+		r.heartbeat = config
+	}
+}
+
+// WithWindow sets the bytes the server may send on each connection before the target has accepted them
+func WithWindow(size int) RouterOption {
+	return func(r *Router) {
+		r.window = size
+	}
+}
+
+// WithPriorities sets the scheduling class of tunnels, the requests of higher classes are sent to the server first.
+// Tunnels default to class 0.
+func WithPriorities(priorities map[string]int) RouterOption {
+	return func(r *Router) {
+		r.priorities = priorities
+	}
+}
+
+// WithCompression sets the algorithm compressing the data sent to the server on each tunnel, if the server
+// supports it. Tunnels default to no compression.
+func WithCompression(algorithms map[string]compress.Algorithm) RouterOption {
+	return func(r *Router) {
+		r.compression = algorithms
+	}
+}
+
+// WithCompressionThreshold sets the size below which data is sent uncompressed
+func WithCompressionThreshold(size int) RouterOption {
+	return func(r *Router) {
+		r.threshold = size
+	}
+}
+
+// WithProxyProtocol sets the version of the PROXY protocol header announcing the public peer to the target
+// of each tunnel. Tunnels default to no header.
+func WithProxyProtocol(versions map[string]proxyproto.Version) RouterOption {
+	return func(r *Router) {
+		r.proxy = versions
+	}
+}
+
+// WithDestinations registers tunnels whose connections dial the destination the server gives for each of them,
+// such as those of SOCKS peers, instead of a target. Destinations must be allowed by the list of the tunnel.
+func WithDestinations(lists map[string]*allow.List) RouterOption {
+	return func(r *Router) {
+		r.destinations = lists
+	}
+}
+
+// WithStreamPerConnection asks the server to carry each connection on its own stream, if the server supports it
+func WithStreamPerConnection(enabled bool) RouterOption {
+	return func(r *Router) {
+		r.streamPerConnection = enabled
+	}
+}
+
+// WithStreams sets the number of Tunnel streams opened in parallel, the server spreads connections across them
+func WithStreams(n int) RouterOption {
+	return func(r *Router) {
+		if n > 0 {
+			r.streams = n
+		}
+	}
+}
+
+// WithClients adds connections to the server the streams are spread over
+func WithClients(clients ...tunnelv1.TunnelServiceClient) RouterOption {
+	return func(r *Router) {
+		r.clients = append(r.clients, clients...)
+	}
+}
+
+// NewRouter creates a router serving the given tunnels, keyed by name with the target address as value.
+// The weight is the share of connections the router asks for when the server balances randomly.
+func NewRouter(log *zap.Logger, client tunnelv1.TunnelServiceClient, targets map[string]string, weight int, opts ...RouterOption) *Router {
+	r := &Router{log: log, clients: []tunnelv1.TunnelServiceClient{client}, streams: 1, targets: targets, weight: weight, window: flow.DefaultWindow, threshold: compress.DefaultThreshold, backoff: DefaultBackoff, heartbeat: heartbeat.Default, connections: make(map[string]*connection), dials: make(map[uint64]net.Conn)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// State returns the current connection state
+func (r *Router) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// RTT returns the round-trip time of the last heartbeat answered by the server, 0 until one is
+func (r *Router) RTT() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rtt
+}
+
+func (r *Router) setState(state State, fields ...zap.Field) {
+	r.mu.Lock()
+	from := r.state
+	r.state = state
+	r.mu.Unlock()
+	if from != state {
+		r.log.Info("client state changed", append([]zap.Field{zap.Stringer("from", from), zap.Stringer("to", state)}, fields...)...)
+	}
+}
+
+// statusCode returns the gRPC code of a possibly wrapped error
+func statusCode(err error) codes.Code {
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		return se.GRPCStatus().Code()
+	}
+	return status.Code(err)
+}
+
+// Run serves the tunnels until ctx is done, re-establishing the stream with backoff whenever it breaks.
+// It gives up when the server rejects the client credentials or the client cannot talk to the server.
+func (r *Router) Run(ctx context.Context) error {
+	attempt := 0
+	for {
+		r.setState(StateConnecting)
+		err := r.Start(ctx)
+		if ctx.Err() != nil {
+			r.setState(StateStopped)
+			return nil
+		}
+		if c := statusCode(err); c == codes.Unauthenticated || c == codes.PermissionDenied || c == codes.FailedPrecondition || errors.Is(err, protocol.ErrIncompatible) {
+			r.setState(StateStopped, zap.Error(err))
+			return err
+		}
+		if r.State() == StateConnected {
+			attempt = 0
+		}
+		if err == nil {
+			err = errStreamClosed
+		}
+		delay := r.backoff.Delay(attempt)
+		attempt++
+		r.setState(StateReconnecting, zap.Error(err), zap.Duration("retryIn", delay), zap.Int("attempt", attempt))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			r.setState(StateStopped)
+			return nil
+		}
+	}
+}
+
+// register sends the tunnels served by the router, resuming session if set, and returns the settings accepted by the server
+func (r *Router) register(stream tunnelv1.TunnelService_TunnelClient, pool, session string) (registration, error) {
+	agreement, err := r.hello(stream)
+	if err != nil {
+		return registration{}, err
+	}
+	caps := agreement.Capabilities
+	if !caps.Has(protocol.Pool) {
+		pool = ""
+	}
+	if !caps.Has(protocol.Resume) {
+		session = ""
+	}
+	tunnels := make([]string, 0, len(r.targets)+len(r.destinations))
+	for name := range r.targets {
+		tunnels = append(tunnels, name)
+	}
+	for name := range r.destinations {
+		if _, ok := r.targets[name]; !ok {
+			tunnels = append(tunnels, name)
+		}
+	}
+	sort.Strings(tunnels)
+	req := &tunnelv1.TunnelRequest{
+		Type:                tunnelv1.RequestType_REGISTER,
+		Tunnels:             tunnels,
+		Weight:              uint32(r.weight),
+		Window:              uint32(r.window),
+		StreamPerConnection: r.streamPerConnection && caps.Has(protocol.StreamPerConnection),
+		Pool:                pool,
+		SessionId:           session,
+	}
+	if err := stream.Send(req); err != nil {
+		return registration{}, fmt.Errorf("cannot register tunnels: %w", err)
+	}
+	in, err := stream.Recv()
+	if err != nil {
+		return registration{}, fmt.Errorf("cannot register tunnels: %w", err)
+	}
+	if in.Type != tunnelv1.ResponseType_REGISTERED {
+		return registration{}, fmt.Errorf("cannot register tunnels: unexpected response %v", in.Type)
+	}
+	r.log.Info("registered tunnels", zap.String("session", in.SessionId), zap.Strings("tunnels", tunnels), zap.Bool("streamPerConnection", in.StreamPerConnection), zap.Bool("resumed", in.Resumed))
+	reg := registration{
+		window:              int(in.Window),
+		streamPerConnection: r.streamPerConnection && in.StreamPerConnection,
+		session:             in.SessionId,
+		resumed:             in.Resumed,
+		grace:               time.Duration(in.GracePeriod) * time.Millisecond,
+		capabilities:        caps,
+	}
+	if reg.window == 0 {
+		reg.window = flow.DefaultWindow
+	}
+	return reg, nil
+}
+
+// hello introduces the router to the server and returns what they agreed on
+func (r *Router) hello(stream tunnelv1.TunnelService_TunnelClient) (protocol.Agreement, error) {
+	req := &tunnelv1.TunnelRequest{
+		Type:  tunnelv1.RequestType_HELLO,
+		Hello: &tunnelv1.Hello{Protocol: protocol.Version, Version: r.version, Capabilities: protocol.Supported.List()},
+	}
+	if err := stream.Send(req); err != nil {
+		return protocol.Agreement{}, fmt.Errorf("cannot say hello: %w", err)
+	}
+	in, err := stream.Recv()
+	if err != nil {
+		return protocol.Agreement{}, fmt.Errorf("cannot say hello: %w", err)
+	}
+	if in.Type != tunnelv1.ResponseType_HELLO_ACK {
+		return protocol.Agreement{}, fmt.Errorf("cannot say hello: unexpected response %v", in.Type)
+	}
+	agreement, err := protocol.Negotiate(in.Hello.GetProtocol(), in.Hello.GetCapabilities())
+	if err != nil {
+		return protocol.Agreement{}, fmt.Errorf("cannot talk to server %s: %w", in.Hello.GetVersion(), err)
+	}
+	r.log.Debug("server hello", zap.String("serverVersion", in.Hello.GetVersion()), zap.Uint32("protocol", agreement.Version), zap.Strings("capabilities", agreement.Capabilities.List()))
+	return agreement, nil
+}
+
+func (r *Router) connection(id string) (*connection, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.connections[id]
+	return c, ok
+}
+
+func (r *Router) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.connections, id)
+}
+
+// send queues a request for the stream, the scheduler decides when it is sent
+func (r *Router) send(ob *outbox, priority int, req *tunnelv1.TunnelRequest) {
+	ob.Push(queue(req), priority, len(req.Data), req)
+}
+
+// queue returns the scheduler queue of a request, that of its connection if it has one
+func queue(req *tunnelv1.TunnelRequest) string {
+	switch {
+	case req.ConnectionId != "":
+		return req.ConnectionId
+	case req.Type == tunnelv1.RequestType_PING || req.Type == tunnelv1.RequestType_PONG:
+		return heartbeatQueue
+	default:
+		return dialQueue
+	}
+}
+
+// sendAll sends the scheduled requests until ctx is done or the stream breaks
+func (r *Router) sendAll(ctx context.Context, stream tunnelv1.TunnelService_TunnelClient, ob *outbox) error {
+	for {
+		req, ok := ob.Pop(ctx.Done())
+		if !ok {
+			return nil
+		}
+		if err := stream.Send(req); err != nil {
+			return err
+		}
+	}
+}
+
+// openFailed tells the server the target of a connection could not be reached
+func (r *Router) openFailed(ob *outbox, in *tunnelv1.TunnelResponse, err error) {
+	r.log.Warn("cannot open connection", zap.String("connectionId", in.ConnectionId), zap.Error(err))
+	req := &tunnelv1.TunnelRequest{ConnectionId: in.ConnectionId, Type: tunnelv1.RequestType_OPEN_FAILED, Reason: err.Error(), Failure: failureOf(err)}
+	r.send(ob, r.priorities[in.Tunnel], req)
+}
+
+// failureOf tells the server what kind of error kept a connection from its target, for it to answer public peers
+// that asked for a destination
+func failureOf(err error) tunnelv1.Failure {
+	var op *net.OpError
+	switch {
+	case errors.Is(err, allow.ErrDenied):
+		return tunnelv1.Failure_DENIED
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return tunnelv1.Failure_REFUSED
+	case errors.As(err, &op) && op.Op == "dial":
+		return tunnelv1.Failure_UNREACHABLE
+	default:
+		return tunnelv1.Failure_OTHER
+	}
+}
+
+// connectionInfo returns the description of the public side of a connection sent by the server
+func connectionInfo(info *tunnelv1.ConnectionInfo) ConnectionInfo {
+	i := ConnectionInfo{RemoteAddr: info.GetRemoteAddress(), LocalAddr: info.GetLocalAddress(), ServerName: info.GetServerName()}
+	if info.GetAcceptedAt() != 0 {
+		i.AcceptedAt = time.Unix(0, info.GetAcceptedAt())
+	}
+	return i
+}
+
+func (r *Router) open(ctx context.Context, ob *outbox, in *tunnelv1.TunnelResponse, reg registration) {
+	var target string
+	var opts []HandlerOption
+	if in.DialId != 0 {
+		conn := r.takeDial(in.DialId)
+		if conn == nil {
+			r.openFailed(ob, in, fmt.Errorf("connection of dial %d closed", in.DialId))
+			return
+		}
+		opts = append(opts, WithConn(conn))
+	} else {
+		if in.Destination != "" {
+			if _, ok := r.destinations[in.Tunnel]; !ok {
+				r.openFailed(ob, in, fmt.Errorf("%w: tunnel %q does not dial destinations", allow.ErrDenied, in.Tunnel))
+				return
+			}
+		} else {
+			var ok bool
+			if target, ok = r.targets[in.Tunnel]; !ok {
+				r.openFailed(ob, in, fmt.Errorf("unknown tunnel %q", in.Tunnel))
+				return
+			}
+		}
+		opts = append(opts, WithInfo(connectionInfo(in.Info)))
+		if in.Network == tunnelv1.Network_UDP {
+			opts = append(opts, WithNetwork("udp"))
+		} else if v, ok := r.proxy[in.Tunnel]; ok {
+			opts = append(opts, WithProxyHeader(v))
+		}
+	}
+	out := make(chan []byte)
+	// dialed connections share the stream of their dial
+	dedicated := reg.streamPerConnection && in.DialId == 0
+	c := &connection{
+		ConnectionHandler: NewConnectionHandler(r.log, in.ConnectionId, target, make(chan []byte), out, opts...),
+		input:             flow.NewBuffer(r.window),
+		written:           make(chan struct{}),
+		window:            flow.NewWindow(reg.window),
+		priority:          r.priorities[in.Tunnel],
+		network:           in.Network,
+		ob:                ob,
+		dedicated:         dedicated,
+	}
+	if alg := r.compression[in.Tunnel]; reg.capabilities.Has(alg.Capability()) && in.Network == tunnelv1.Network_TCP {
+		c.encoder = compress.Encoder{Algorithm: alg, Threshold: r.threshold}
+	}
+	// the data is only kept while the server would hold the connection for the stream to resume
+	if reg.grace > 0 && reg.capabilities.Has(protocol.Resume) && in.Network == tunnelv1.Network_TCP {
+		c.replay = flow.NewReplay()
+	}
+	r.mu.Lock()
+	r.connections[in.ConnectionId] = c
+	r.mu.Unlock()
+	go func() {
+		defer r.remove(in.ConnectionId)
+		if in.Destination != "" {
+			// dial the address the list allowed, which a name may have been resolved to
+			addr, err := r.destinations[in.Tunnel].Allow(ctx, in.Destination)
+			if err != nil {
+				c.Close()
+				r.openFailed(ob, in, err)
+				return
+			}
+			c.target = addr
+		}
+		if err := c.Open(); err != nil {
+			c.Close()
+			r.openFailed(ob, in, err)
+			return
+		}
+		if c.dedicated {
+			cob, err := r.connect(ctx, c)
+			if err != nil {
+				c.Close()
+				r.openFailed(ob, in, err)
+				return
+			}
+			c.ob = cob
+		} else {
+			r.send(ob, c.priority, &tunnelv1.TunnelRequest{ConnectionId: in.ConnectionId, Type: tunnelv1.RequestType_OPEN_ACK})
+		}
+		finished := make(chan struct{})
+		go func() {
+			c.Run()
+			close(finished)
+		}()
+		go r.write(c)
+		r.forward(ctx, c, out, finished)
+		<-finished
+		c.Close()
+		// tell the server unless it closed the connection itself
+		if _, ok := r.connection(in.ConnectionId); !ok {
+			return
+		}
+		r.send(c.ob, c.priority, &tunnelv1.TunnelRequest{ConnectionId: in.ConnectionId, Type: tunnelv1.RequestType_CLOSE})
+	}()
+}
+
+// Forward accepts connections on l until ctx is done. The server dials destination for each of them and the
+// connection is carried over the tunnel stream, like the local forwards of ssh.
+func (r *Router) Forward(ctx context.Context, l net.Listener, destination string) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		r.dial(conn, destination)
+	}
+}
+
+// dial asks the server to connect to destination for an accepted connection, which is closed if the server
+// cannot or does not answer in time
+func (r *Router) dial(conn net.Conn, destination string) {
+	r.mu.Lock()
+	if r.state != StateConnected || !r.dialing {
+		state, dialing := r.state, r.dialing
+		r.mu.Unlock()
+		r.log.Warn("cannot forward connection", zap.String("destination", destination), zap.Stringer("state", state), zap.Bool("serverDials", dialing))
+		conn.Close()
+		return
+	}
+	r.dialID++
+	id := r.dialID
+	r.dials[id] = conn
+	ob := r.outboxes[0]
+	r.mu.Unlock()
+	r.log.Debug("dialing destination", zap.Uint64("dialId", id), zap.String("destination", destination), zap.String("remoteAddress", conn.RemoteAddr().String()))
+	r.send(ob, 0, &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_DIAL, DialId: id, Destination: destination})
+	time.AfterFunc(dialWait, func() {
+		if c := r.takeDial(id); c != nil {
+			r.log.Warn("closing forwarded connection, the server did not dial its destination in time", zap.Uint64("dialId", id), zap.String("destination", destination))
+			c.Close()
+		}
+	})
+}
+
+// takeDial returns the accepted connection of a dial and forgets it, nil if it is closed
+func (r *Router) takeDial(id uint64) net.Conn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conn := r.dials[id]
+	delete(r.dials, id)
+	return conn
+}
+
+// connect opens the stream carrying a connection on its own and returns the outbox of the stream.
+// The stream ends once the connection is closed.
+func (r *Router) connect(ctx context.Context, c *connection) (*outbox, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := r.clients[0].Connect(ctx)
 	if err != nil {
-		r.log.Fatal("cannot create tunnel: ", zap.Error(err))
+		cancel()
+		return nil, fmt.Errorf("cannot open connection stream: %w", err)
+	}
+	ob := flow.NewScheduler[*tunnelv1.TunnelRequest](flow.DefaultQuantum)
+	r.send(ob, c.priority, &tunnelv1.TunnelRequest{ConnectionId: c.connectionId, Type: tunnelv1.RequestType_OPEN_ACK})
+	go func() {
+		for {
+			req, ok := ob.Pop(ctx.Done())
+			if !ok {
+				return
+			}
+			if err := stream.Send(req); err != nil {
+				cancel()
+				return
+			}
+			if req.Type == tunnelv1.RequestType_CLOSE {
+				stream.CloseSend()
+				return
+			}
+		}
+	}()
+	go func() {
+		defer cancel()
+		for {
+			in, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					r.log.Warn("connection stream broken", zap.String("connectionId", c.connectionId), zap.Error(err))
+				}
+				c.Close()
+				return
+			}
+			r.dispatch(in)
+		}
+	}()
+	return ob, nil
+}
+
+// write hands the data queued for a connection to its target, closing the write side once the server stops sending
+func (r *Router) write(c *connection) {
+	defer close(c.written)
+	for {
+		data, ok := c.input.Pop(c.Done())
+		if !ok {
+			select {
+			case <-c.Done():
+			default:
+				c.CloseWrite()
+			}
+			return
+		}
+		c.Write(data)
+	}
+}
+
+// forward sends the data read from the target within the credit granted by the server, then tells the server
+// the target closed its write side. Until the handler finishes it also grants the server credit as the input is consumed.
+func (r *Router) forward(ctx context.Context, c *connection, out chan []byte, finished <-chan struct{}) {
+	var pending []byte
+	for {
+		if n := c.window.Take(len(pending)); n > 0 {
+			r.sendData(c, pending[:n])
+			pending = pending[n:]
+			continue
+		}
+		// read from the target only once the pending data is sent
+		data, credit := out, (<-chan struct{})(nil)
+		if len(pending) > 0 {
+			data, credit = nil, c.window.Ready()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-finished:
+			return
+		case <-c.Done():
+			return
+		case <-credit:
+		case <-c.input.Updates():
+			r.sendWindow(c)
+		case d, ok := <-data:
+			if !ok {
+				out = nil
+				r.sendCloseWrite(c)
+				continue
+			}
+			if c.network == tunnelv1.Network_UDP {
+				r.sendDatagram(c, d)
+				continue
+			}
+			pending = d
+		}
+	}
+}
+
+// sendData queues data read from the target, keeping it to send again if the session is resumed
+func (r *Router) sendData(c *connection, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seq := c.sent
+	c.sent += int64(len(data))
+	if c.replay != nil {
+		c.replay.Append(data)
+	}
+	data, alg := c.encoder.Encode(data)
+	req := &tunnelv1.TunnelRequest{ConnectionId: c.connectionId, Type: tunnelv1.RequestType_DATA_RESPONSE, Data: data, Seq: uint64(seq), Compression: alg.Proto()}
+	r.send(c.ob, c.priority, req)
+}
+
+// sendDatagram queues a packet read from the target whole, or drops it if the server has no room for it.
+// Packets are not kept since UDP does not send them again.
+func (r *Router) sendDatagram(c *connection, data []byte) {
+	if !c.window.TakeAll(len(data)) {
+		r.log.Debug("dropping packet, no credit", zap.String("connectionId", c.connectionId), zap.Int("size", len(data)))
+		return
+	}
+	r.send(c.ob, c.priority, &tunnelv1.TunnelRequest{ConnectionId: c.connectionId, Type: tunnelv1.RequestType_DATAGRAM, Data: data})
+}
+
+// sendCloseWrite queues the end of the data read from the target, keeping it to send again if the session is resumed
+func (r *Router) sendCloseWrite(c *connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replay != nil {
+		c.replay.Close()
+	}
+	r.send(c.ob, c.priority, &tunnelv1.TunnelRequest{ConnectionId: c.connectionId, Type: tunnelv1.RequestType_CLOSE_WRITE, Seq: uint64(c.sent)})
+}
+
+// sendWindow grants the server the credit of the input consumed
+func (r *Router) sendWindow(c *connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r.send(c.ob, c.priority, &tunnelv1.TunnelRequest{ConnectionId: c.connectionId, Type: tunnelv1.RequestType_WINDOW_UPDATE, Window: uint32(c.input.Release())})
+}
+
+// sendResume tells the server what a connection received so it sends again what was lost with the stream
+func (r *Router) sendResume(c *connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	received, consumed := c.input.Position()
+	r.send(c.ob, c.priority, &tunnelv1.TunnelRequest{ConnectionId: c.connectionId, Type: tunnelv1.RequestType_RESUME, Seq: uint64(received), Ack: uint64(consumed)})
+}
+
+// replay restores the credit the server granted while the stream was broken and sends again the data it missed
+func (r *Router) replay(c *connection, seq, ack int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replay == nil {
+		return
+	}
+	c.window.Add(c.replay.AckTo(ack))
+	// the server received at least what it consumed, so the data kept starts at or before seq
+	chunks, end, closed := c.replay.From(seq)
+	for _, data := range chunks {
+		wire, alg := c.encoder.Encode(data)
+		r.send(c.ob, c.priority, &tunnelv1.TunnelRequest{ConnectionId: c.connectionId, Type: tunnelv1.RequestType_DATA_RESPONSE, Data: wire, Seq: uint64(seq), Compression: alg.Proto()})
+		seq += int64(len(data))
+	}
+	if closed {
+		r.send(c.ob, c.priority, &tunnelv1.TunnelRequest{ConnectionId: c.connectionId, Type: tunnelv1.RequestType_CLOSE_WRITE, Seq: uint64(end)})
+	}
+}
+
+// Start registers the tunnels on the pool of streams and serves them until one of the streams ends.
+// The connections of the previous streams carry on if the server resumes their sessions.
+func (r *Router) Start(ctx context.Context) error {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if r.streams > 1 && r.pool == "" {
+		r.pool = uuid.New().String()
+	}
+	if r.outboxes == nil {
+		r.sessions = make([]string, r.streams)
+		r.resetOutboxes()
+	}
+	streams := make([]tunnelv1.TunnelService_TunnelClient, r.streams)
+	regs := make([]registration, r.streams)
+	resumed := true
+	for i := range streams {
+		// streams are spread over the connections to the server
+		stream, err := r.clients[i%len(r.clients)].Tunnel(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot create tunnel: %w", err)
+		}
+		reg, err := r.register(stream, r.pool, r.sessions[i])
+		if err != nil {
+			return err
+		}
+		streams[i], regs[i] = stream, reg
+		r.sessions[i] = reg.session
+		resumed = resumed && reg.resumed
+	}
+	if r.expiry != nil {
+		r.expiry.Stop()
+	}
+	r.mu.Lock()
+	r.dialing = regs[0].capabilities.Has(protocol.Dial)
+	r.mu.Unlock()
+	if resumed {
+		r.resumeAll()
+	} else {
+		// the server lost the connections, those resumed by other streams are closed when it resumes them
+		r.closeAll()
+		r.resetOutboxes()
+	}
+	r.setState(StateConnected)
+
+	errc := make(chan error, len(streams))
+	for i := range streams {
+		i := i
+		go func() {
+			errc <- r.serve(ctx, parent, cancel, streams[i], regs[i], r.outboxes[i])
+		}()
+	}
+	// the pool is re-established as a whole
+	err := <-errc
+	cancel()
+	for i := 1; i < len(streams); i++ {
+		<-errc
 	}
+	r.detach(parent, regs[0].grace)
+	return err
+}
+
+// resetOutboxes drops the requests queued for the sessions the server did not resume
+func (r *Router) resetOutboxes() {
+	outboxes := make([]*outbox, r.streams)
+	for i := range outboxes {
+		outboxes[i] = flow.NewScheduler[*tunnelv1.TunnelRequest](flow.DefaultQuantum)
+	}
+	r.mu.Lock()
+	r.outboxes = outboxes
+	r.mu.Unlock()
+}
+
+// detach keeps the connections of the broken streams for as long as the server holds them, so that their sessions
+// can be resumed. They are closed at once if the router stops or the server does not hold them.
+func (r *Router) detach(ctx context.Context, grace time.Duration) {
+	if grace <= 0 || ctx.Err() != nil {
+		r.closeAll()
+		return
+	}
+	r.expiry = time.AfterFunc(grace, r.closeAll)
+}
+
+// resumeAll tells the server what each connection received once the sessions are resumed.
+// The server closes the UDP sessions instead.
+func (r *Router) resumeAll() {
+	r.mu.Lock()
+	connections := make([]*connection, 0, len(r.connections))
+	for _, c := range r.connections {
+		// connections on their own stream are not resumed
+		if !c.dedicated && c.network == tunnelv1.Network_TCP {
+			connections = append(connections, c)
+		}
+	}
+	r.mu.Unlock()
+	for _, c := range connections {
+		r.sendResume(c)
+	}
+}
+
+// serve handles the connections opened on a registered stream until it ends, the connections live until parent is done.
+// abort tears down the streams of the pool once the server stops answering heartbeats.
+func (r *Router) serve(ctx, parent context.Context, abort context.CancelFunc, stream tunnelv1.TunnelService_TunnelClient, reg registration, ob *outbox) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sendErr := make(chan error, 1)
+	sent := make(chan struct{})
+	// the outbox outlives the stream, it must not be drained by a sender of a broken one
+	defer func() {
+		cancel()
+		<-sent
+	}()
+	go func() {
+		defer close(sent)
+		if err := r.sendAll(ctx, stream, ob); err != nil {
+			sendErr <- err
+			cancel()
+		}
+	}()
+	hb := r.heartbeat
+	if !reg.capabilities.Has(protocol.Heartbeat) {
+		hb.Interval = 0
+	}
+	monitor := heartbeat.NewMonitor(hb)
+	deadErr := make(chan error, 1)
+	go func() {
+		err := monitor.Run(ctx.Done(), func(ts int64) {
+			r.send(ob, heartbeatPriority, &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_PING, Timestamp: ts})
+		})
+		if err != nil {
+			r.log.Warn("server stopped answering heartbeats", zap.Duration("rtt", monitor.RTT()))
+			deadErr <- err
+			abort()
+		}
+	}()
 	waitc := make(chan struct{})
+	var recvErr error
 	go func() {
 		for {
 			r.log.Debug("waiting for message")
@@ -33,53 +946,135 @@ func (r *Router) Start(ctx context.Context) error {
 				close(waitc)
 				return
 			}
-			switch in.Type {
-			case tunnelv1.ResponseType_OPEN_CONNECTION:
-				r.log.Debug("received open connection")
-				if _, ok := r.connections[in.ConnectionId]; !ok {
-					out := make(chan []byte)
-					r.connections[in.ConnectionId] = NewConnectionHandler(r.log, in.ConnectionId, r.target, make(chan []byte), out)
-					go r.connections[in.ConnectionId].Run()
-					go func() {
-						for {
-							select {
-							case <-ctx.Done():
-								return
-							case <-r.connections[in.ConnectionId].done:
-								return
-							case data := <-out:
-								err := stream.Send(&tunnelv1.TunnelRequest{
-									ConnectionId: in.ConnectionId,
-									Data:         data,
-								})
-								if err != nil {
-									r.log.Fatal("Failed to send data", zap.Error(err))
-								}
-							}
-						}
-					}()
-				}
-				if in.Data != nil && len(in.Data) > 0 {
-					r.connections[in.ConnectionId].in <- in.Data
+			if err != nil {
+				if ctx.Err() == nil {
+					r.log.Error("Failed to receive a note", zap.Error(err))
+					recvErr = err
 				}
-			case tunnelv1.ResponseType_DATA_RECEIVE:
-				r.log.Debug("received data")
-				if c, ok := r.connections[in.ConnectionId]; ok {
-					c.in <- in.Data
+				close(waitc)
+				return
+			}
+			switch in.Type {
+			case tunnelv1.ResponseType_HEARTBEAT_PING:
+				r.send(ob, heartbeatPriority, &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_PONG, Timestamp: in.Timestamp})
+				continue
+			case tunnelv1.ResponseType_HEARTBEAT_PONG:
+				rtt := monitor.Pong(in.Timestamp)
+				r.mu.Lock()
+				r.rtt = rtt
+				r.mu.Unlock()
+				r.log.Debug("heartbeat", zap.Duration("rtt", rtt))
+				continue
+			case tunnelv1.ResponseType_DIAL_FAILED:
+				if conn := r.takeDial(in.DialId); conn != nil {
+					r.log.Warn("server cannot dial destination", zap.Uint64("dialId", in.DialId), zap.String("reason", in.Reason))
+					conn.Close()
 				}
-			case tunnelv1.ResponseType_CLOSE_CONNECTION:
-				r.log.Debug("received close connection")
-				if c, ok := r.connections[in.ConnectionId]; ok {
-					c.Close()
-					delete(r.connections, in.ConnectionId)
+				continue
+			}
+			if in.Type == tunnelv1.ResponseType_OPEN_CONNECTION {
+				r.log.Debug("received open connection", zap.String("tunnel", in.Tunnel), zap.String("remoteAddress", in.Info.GetRemoteAddress()))
+				if _, ok := r.connection(in.ConnectionId); !ok {
+					r.open(parent, ob, in, reg)
 				}
 			}
-			if err != nil {
-				r.log.Fatal("Failed to receive a note", zap.Error(err))
+			if in.Type == tunnelv1.ResponseType_RESUME_CONNECTION {
+				if _, ok := r.connection(in.ConnectionId); !ok {
+					// the connection was closed while the stream was broken
+					r.send(ob, 0, &tunnelv1.TunnelRequest{ConnectionId: in.ConnectionId, Type: tunnelv1.RequestType_CLOSE})
+				}
 			}
+			r.dispatch(in)
 			r.log.Info("received", zap.String("connectionId", in.ConnectionId), zap.ByteString("data", in.Data))
 		}
 	}()
 	<-waitc
-	return nil
+	select {
+	case err := <-sendErr:
+		r.log.Error("Failed to send", zap.Error(err))
+		return err
+	case err := <-deadErr:
+		return err
+	default:
+	}
+	return recvErr
+}
+
+// dispatch hands a response of the server to the connection it belongs to
+func (r *Router) dispatch(in *tunnelv1.TunnelResponse) {
+	c, ok := r.connection(in.ConnectionId)
+	if !ok {
+		return
+	}
+	switch in.Type {
+	case tunnelv1.ResponseType_OPEN_CONNECTION, tunnelv1.ResponseType_DATA_RECEIVE:
+		r.log.Debug("received data")
+		r.receive(c, in)
+	case tunnelv1.ResponseType_DATAGRAM_RECEIVE:
+		// the packet is queued whole, the credit of the server keeps it within the window
+		if err := c.input.Push(in.Data); err != nil {
+			r.log.Warn("closing connection", zap.String("connectionId", c.connectionId), zap.Error(err))
+			c.Close()
+		}
+	case tunnelv1.ResponseType_CLOSE_WRITE_CONNECTION:
+		r.log.Debug("received close write")
+		c.input.CloseAt(int64(in.Seq))
+	case tunnelv1.ResponseType_WINDOW_UPDATE_CONNECTION:
+		c.window.Add(int(in.Window))
+		if c.replay != nil {
+			c.replay.Ack(int(in.Window))
+		}
+	case tunnelv1.ResponseType_RESUME_CONNECTION:
+		if !c.dedicated {
+			r.log.Debug("resuming connection", zap.String("connectionId", in.ConnectionId), zap.Uint64("seq", in.Seq))
+			r.replay(c, int64(in.Seq), int64(in.Ack))
+		}
+	case tunnelv1.ResponseType_CLOSE_CONNECTION:
+		r.log.Debug("received close connection")
+		r.remove(in.ConnectionId)
+		// the data the server sent before closing is still handed to the target
+		c.input.Close()
+		go func() {
+			select {
+			case <-c.written:
+			case <-time.After(lingerTimeout):
+			}
+			c.Close()
+		}()
+	}
+}
+
+// receive queues data for the target without waiting so a slow target does not stall the others
+func (r *Router) receive(c *connection, in *tunnelv1.TunnelResponse) {
+	alg, err := compress.FromProto(in.Compression)
+	if err != nil {
+		r.log.Warn("closing connection", zap.String("connectionId", c.connectionId), zap.Error(err))
+		c.Close()
+		return
+	}
+	// the data of a frame never exceeds the window granted to the server
+	data, err := compress.Decode(alg, in.Data, r.window)
+	if err != nil {
+		r.log.Warn("closing connection, cannot decompress data", zap.String("connectionId", c.connectionId), zap.Error(err))
+		c.Close()
+		return
+	}
+	if err := c.input.PushAt(int64(in.Seq), data); err != nil {
+		r.log.Warn("closing connection", zap.String("connectionId", c.connectionId), zap.Error(err))
+		c.Close()
+	}
+}
+
+// closeAll closes the connections of the finished streams, along with the accepted connections waiting for a dial
+func (r *Router) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, c := range r.connections {
+		c.Close()
+		delete(r.connections, id)
+	}
+	for id, conn := range r.dials {
+		conn.Close()
+		delete(r.dials, id)
+	}
 }
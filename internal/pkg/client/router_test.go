@@ -1,7 +1,1157 @@
 package client
 
-import "testing"
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/allow"
+	"github.com/costap/tunnelv2/internal/pkg/compress"
+	"github.com/costap/tunnelv2/internal/pkg/heartbeat"
+	tunnelv1 "github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	tunnelv2 "github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v2"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"github.com/costap/tunnelv2/internal/pkg/proxyproto"
+	"github.com/costap/tunnelv2/internal/pkg/server/tunnel"
+	"github.com/costap/tunnelv2/internal/pkg/server/udp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/net/nettest"
+	"golang.org/x/net/proxy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
 
-func Router_Start(t *testing.T) {
-	
+// startTarget starts a target replying to every read with its name
+func startTarget(t *testing.T, name string) string {
+	ln, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 1024)
+				for {
+					if _, err := conn.Read(buf); err != nil {
+						return
+					}
+					if _, err := conn.Write([]byte(name)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// testServer serves tunnel services on in-memory listeners
+type testServer struct {
+	mu sync.Mutex
+	ln *bufconn.Listener
+	gs *grpc.Server
+}
+
+// serve stops the current service, if any, and starts serving s
+func (ts *testServer) serve(t *testing.T, s *tunnel.Service) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.gs != nil {
+		ts.gs.Stop()
+	}
+	ts.ln = bufconn.Listen(1 << 20)
+	ts.gs = grpc.NewServer()
+	tunnelv1.RegisterTunnelServiceServer(ts.gs, s)
+	tunnelv2.RegisterTunnelServiceServer(ts.gs, tunnel.NewServiceV2(s))
+	go ts.gs.Serve(ts.ln)
+	gs := ts.gs
+	t.Cleanup(gs.Stop)
+}
+
+func (ts *testServer) dial(ctx context.Context, _ string) (net.Conn, error) {
+	ts.mu.Lock()
+	ln := ts.ln
+	ts.mu.Unlock()
+	return ln.DialContext(ctx)
+}
+
+// conn returns a connection to the served services, whose streams wait for it to be ready rather than fail on a
+// loaded machine
+func (ts *testServer) conn(t *testing.T) *grpc.ClientConn {
+	cc, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(ts.dial),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1, MaxDelay: 10 * time.Millisecond}}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
+
+// startServer starts a tunnel service on an in-memory listener and returns a client for it
+func startServer(t *testing.T, s *tunnel.Service) (tunnelv1.TunnelServiceClient, *testServer) {
+	ts := &testServer{}
+	ts.serve(t, s)
+	return tunnelv1.NewTunnelServiceClient(ts.conn(t)), ts
+}
+
+// registrationTimeout bounds the wait for clients to register, which is slow on loaded machines
+const registrationTimeout = 10 * time.Second
+
+// waitServed waits until clients have registered the tunnels with the service
+func waitServed(t *testing.T, s *tunnel.Service, tunnels ...string) {
+	t.Helper()
+	deadline := time.Now().Add(registrationTimeout)
+	for {
+		served := make(map[string]bool)
+		for _, c := range s.Stats().Clients {
+			for _, name := range c.Tunnels {
+				served[name] = true
+			}
+		}
+		missing := 0
+		for _, name := range tunnels {
+			if !served[name] {
+				missing++
+			}
+		}
+		if missing == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no client registered %v", tunnels)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitConnected waits until the router has registered with the server
+func waitConnected(t *testing.T, r *Router) {
+	t.Helper()
+	deadline := time.Now().Add(registrationTimeout)
+	for r.State() != StateConnected {
+		if time.Now().After(deadline) {
+			t.Fatal("client not registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// roundTrip sends ping through the controller and returns the reply, a client must serve its tunnel
+func roundTrip(t *testing.T, c *tunnel.Controller) string {
+	t.Helper()
+	public, conn := net.Pipe()
+	defer public.Close()
+	go c.Handle(context.Background(), conn)
+	public.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := public.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	n, err := public.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestRouter_Start(t *testing.T) {
+	log := zap.NewNop()
+	s := tunnel.NewService(log)
+	tc, _ := startServer(t, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, name := range []string{"a", "b"} {
+		r := NewRouter(log, tc, map[string]string{name: startTarget(t, name)}, 1)
+		go r.Start(ctx)
+	}
+	waitServed(t, s, "a", "b")
+
+	for _, name := range []string{"a", "b"} {
+		if got := roundTrip(t, tunnel.NewController(log, s, name)); got != name {
+			t.Fatalf("expected reply from %s, got %s", name, got)
+		}
+	}
+}
+
+func TestRouter_V2(t *testing.T) {
+	log := zap.NewNop()
+	s := tunnel.NewService(log, tunnel.WithStreamPerConnection(true))
+	tc, ts := startServer(t, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// clients of both versions share the server, the second carries its connections on their own stream
+	go NewRouter(log, tc, map[string]string{"a": startTarget(t, "a")}, 1).Start(ctx)
+	go NewRouter(log, NewClientV2(ts.conn(t)), map[string]string{"b": startTarget(t, "b")}, 1, WithStreamPerConnection(true)).Start(ctx)
+	go NewRouter(log, NewClientV2(ts.conn(t)), map[string]string{"c": startTarget(t, "c")}, 1).Start(ctx)
+	waitServed(t, s, "a", "b", "c")
+
+	for _, name := range []string{"a", "b", "c"} {
+		if got := roundTrip(t, tunnel.NewController(log, s, name)); got != name {
+			t.Fatalf("expected reply from %s, got %s", name, got)
+		}
+	}
+}
+
+func TestRouter_RunReconnects(t *testing.T) {
+	log := zap.NewNop()
+	tc, ts := startServer(t, tunnel.NewService(log))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewRouter(log, tc, map[string]string{"a": startTarget(t, "a")}, 1,
+		WithBackoff(Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond, Multiplier: 2}))
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	// a restarted server only knows the client once it has registered again
+	s := tunnel.NewService(log)
+	ts.serve(t, s)
+	waitServed(t, s, "a")
+	if got := roundTrip(t, tunnel.NewController(log, s, "a")); got != "a" {
+		t.Fatalf("expected reply from a, got %s", got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("router did not stop")
+	}
+	if r.State() != StateStopped {
+		t.Fatalf("expected state %s, got %s", StateStopped, r.State())
+	}
+}
+
+func TestRouter_Resume(t *testing.T) {
+	log := zap.NewNop()
+	s := tunnel.NewService(log, tunnel.WithGracePeriod(5*time.Second))
+	tc, ts := startServer(t, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := NewRouter(log, tc, map[string]string{"a": startTarget(t, "a")}, 1,
+		WithBackoff(Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond, Multiplier: 2}))
+	go r.Run(ctx)
+	waitServed(t, s, "a")
+
+	c := tunnel.NewController(log, s, "a")
+	if got := roundTrip(t, c); got != "a" {
+		t.Fatalf("expected reply from a, got %s", got)
+	}
+	public, conn := net.Pipe()
+	defer public.Close()
+	go c.Handle(ctx, conn)
+	public.SetDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	if _, err := public.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := public.Read(buf); err != nil || string(buf[:n]) != "a" {
+		t.Fatalf("expected reply from a, got %s %v", buf[:n], err)
+	}
+
+	// the stream breaks while the public connection is open and sending
+	ts.serve(t, s)
+	if _, err := public.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := public.Read(buf); err != nil || string(buf[:n]) != "a" {
+		t.Fatalf("expected reply from a once the session is resumed, got %s %v", buf[:n], err)
+	}
+}
+
+// silentService registers clients and then never answers them
+type silentService struct {
+	tunnelv1.UnimplementedTunnelServiceServer
+	// protocol is the version of the protocol it says hello with
+	protocol uint32
+}
+
+func (ss silentService) Tunnel(stream tunnelv1.TunnelService_TunnelServer) error {
+	for _, rt := range []tunnelv1.ResponseType{tunnelv1.ResponseType_HELLO_ACK, tunnelv1.ResponseType_REGISTERED} {
+		if _, err := stream.Recv(); err != nil {
+			return err
+		}
+		resp := &tunnelv1.TunnelResponse{Type: rt, Hello: &tunnelv1.Hello{Protocol: ss.protocol, Capabilities: protocol.Supported.List()}}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+// startSilentServer starts a silentService and returns a client for it
+func startSilentServer(t *testing.T, version uint32) tunnelv1.TunnelServiceClient {
+	ts := &testServer{}
+	ts.ln = bufconn.Listen(1 << 20)
+	ts.gs = grpc.NewServer()
+	tunnelv1.RegisterTunnelServiceServer(ts.gs, silentService{protocol: version})
+	go ts.gs.Serve(ts.ln)
+	t.Cleanup(ts.gs.Stop)
+	cc, err := grpc.Dial("bufnet", grpc.WithContextDialer(ts.dial), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return tunnelv1.NewTunnelServiceClient(cc)
+}
+
+func TestRouter_Heartbeat(t *testing.T) {
+	log := zap.NewNop()
+	hb := heartbeat.Config{Interval: 10 * time.Millisecond, Misses: 3}
+	s := tunnel.NewService(log, tunnel.WithHeartbeat(hb))
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := NewRouter(log, tc, map[string]string{"a": startTarget(t, "a")}, 1, WithHeartbeat(hb))
+	go r.Start(ctx)
+
+	for i := 0; i < 50 && (r.RTT() == 0 || len(s.Stats().Clients) == 0 || s.Stats().Clients[0].RTT == 0); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if r.RTT() == 0 {
+		t.Fatal("expected the client to measure the round-trip time")
+	}
+	if clients := s.Stats().Clients; len(clients) != 1 || clients[0].RTT == 0 {
+		t.Fatalf("expected the server to measure the round-trip time of the client, got %+v", clients)
+	}
+}
+
+func TestRouter_HeartbeatDeadServer(t *testing.T) {
+	r := NewRouter(zap.NewNop(), startSilentServer(t, protocol.Version), map[string]string{"a": "localhost:1"}, 1,
+		WithHeartbeat(heartbeat.Config{Interval: 10 * time.Millisecond, Misses: 2}))
+	errc := make(chan error, 1)
+	go func() {
+		errc <- r.Start(context.Background())
+	}()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, heartbeat.ErrPeerDead) {
+			t.Fatalf("expected %v, got %v", heartbeat.ErrPeerDead, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to be torn down")
+	}
+}
+
+func TestRouter_IncompatibleServer(t *testing.T) {
+	r := NewRouter(zap.NewNop(), startSilentServer(t, protocol.MinVersion-1), map[string]string{"a": "localhost:1"}, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- r.Run(context.Background())
+	}()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, protocol.ErrIncompatible) {
+			t.Fatalf("expected %v, got %v", protocol.ErrIncompatible, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the router to give up")
+	}
+}
+
+func TestRouter_OpenFailed(t *testing.T) {
+	ln, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreachable := ln.Addr().String()
+	ln.Close()
+
+	log := zap.NewNop()
+	s := tunnel.NewService(log)
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewRouter(log, tc, map[string]string{"a": unreachable}, 1).Start(ctx)
+	waitServed(t, s, "a")
+
+	public, conn := net.Pipe()
+	defer public.Close()
+	done := make(chan struct{})
+	go func() {
+		tunnel.NewController(log, s, "a").Handle(context.Background(), conn)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("public connection not closed")
+	}
+	if got := s.Stats().OpenFailed; got != 1 {
+		t.Fatalf("expected 1 failed open, got %d", got)
+	}
+}
+
+// startReplyTarget starts a target replying only once the public side has finished sending
+func startReplyTarget(t *testing.T) string {
+	target, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { target.Close() })
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				data, _ := io.ReadAll(conn)
+				conn.Write(append([]byte("got "), data...))
+			}()
+		}
+	}()
+	return target.Addr().String()
+}
+
+// halfClose sends data on a public TCP connection handled by the controller, shuts down its write side and returns
+// the reply, a client must serve its tunnel
+func halfClose(t *testing.T, ctx context.Context, c *tunnel.Controller, data []byte) []byte {
+	ln, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go c.Handle(ctx, conn)
+		}
+	}()
+
+	public, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer public.Close()
+	public.SetDeadline(time.Now().Add(5 * time.Second))
+	go func() {
+		public.Write(data)
+		public.(*net.TCPConn).CloseWrite()
+	}()
+	reply, err := io.ReadAll(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reply
+}
+
+func TestRouter_HalfClose(t *testing.T) {
+	log := zap.NewNop()
+	s := tunnel.NewService(log)
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewRouter(log, tc, map[string]string{"a": startReplyTarget(t)}, 1).Start(ctx)
+
+	waitServed(t, s, "a")
+	if reply := halfClose(t, ctx, tunnel.NewController(log, s, "a"), []byte("ping")); string(reply) != "got ping" {
+		t.Fatalf("expected reply after half-close, got %q", reply)
+	}
+}
+
+func TestRouter_ConnectionInfo(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	s := tunnel.NewService(zap.NewNop())
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewRouter(zap.New(core), tc, map[string]string{"a": startReplyTarget(t)}, 1).Start(ctx)
+
+	before := time.Now()
+	waitServed(t, s, "a")
+	halfClose(t, ctx, tunnel.NewController(zap.NewNop(), s, "a"), []byte("ping"))
+	entries := logs.FilterMessage("connected to target").All()
+	if len(entries) == 0 {
+		t.Fatal("expected the handler to log the connection")
+	}
+	peer, _ := entries[len(entries)-1].ContextMap()["peer"].(map[string]interface{})
+	if remote, _ := peer["remoteAddress"].(string); !strings.HasPrefix(remote, "127.0.0.1:") {
+		t.Fatalf("expected the address of the public peer, got %v", peer)
+	}
+	if accepted, _ := peer["acceptedAt"].(time.Time); accepted.Before(before.Add(-time.Second)) {
+		t.Fatalf("expected the time the connection was accepted, got %v", peer)
+	}
+}
+
+func TestRouter_Compression(t *testing.T) {
+	log := zap.NewNop()
+	s := tunnel.NewService(log, tunnel.WithCompression(map[string]compress.Algorithm{"a": compress.Zstd}))
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewRouter(log, tc, map[string]string{"a": startReplyTarget(t)}, 1,
+		WithCompression(map[string]compress.Algorithm{"a": compress.Gzip})).Start(ctx)
+
+	data := bytes.Repeat([]byte(`{"level":"INFO","msg":"compressible"}`), 2000)
+	waitServed(t, s, "a")
+	if reply := halfClose(t, ctx, tunnel.NewController(log, s, "a"), data); !bytes.Equal(reply, append([]byte("got "), data...)) {
+		t.Fatalf("expected the data back, got %d bytes", len(reply))
+	}
+	stats := s.Stats()
+	if stats.CompressedSent.Raw == 0 || stats.CompressedSent.Ratio() >= 1 {
+		t.Fatalf("expected the data sent to be compressed, got %+v", stats.CompressedSent)
+	}
+	if stats.CompressedReceived.Raw == 0 || stats.CompressedReceived.Ratio() >= 1 {
+		t.Fatalf("expected the data received to be compressed, got %+v", stats.CompressedReceived)
+	}
+}
+
+func TestRouter_ProxyProtocol(t *testing.T) {
+	log := zap.NewNop()
+	s := tunnel.NewService(log)
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	targets := map[string]string{"a": startReplyTarget(t), "b": startReplyTarget(t)}
+	go NewRouter(log, tc, targets, 1, WithProxyProtocol(map[string]proxyproto.Version{"a": proxyproto.V1})).Start(ctx)
+
+	waitServed(t, s, "a", "b")
+	reply := string(halfClose(t, ctx, tunnel.NewController(log, s, "a"), []byte("ping")))
+	if !strings.HasPrefix(reply, "got PROXY TCP4 127.0.0.1 127.0.0.1 ") || !strings.HasSuffix(reply, "\r\nping") {
+		t.Fatalf("expected a PROXY header before the data, got %q", reply)
+	}
+	if reply := string(halfClose(t, ctx, tunnel.NewController(log, s, "b"), []byte("ping"))); reply != "got ping" {
+		t.Fatalf("expected the data alone, got %q", reply)
+	}
+}
+
+// startUDPTarget starts a UDP target answering each packet with "got " and the packet
+func startUDPTarget(t *testing.T) string {
+	target, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { target.Close() })
+	go func() {
+		buf := make([]byte, 64<<10)
+		for {
+			n, addr, err := target.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			target.WriteTo(append([]byte("got "), buf[:n]...), addr)
+		}
+	}()
+	return target.LocalAddr().String()
+}
+
+func TestRouter_UDP(t *testing.T) {
+	log := zap.NewNop()
+	s := tunnel.NewService(log)
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewRouter(log, tc, map[string]string{"dns": startUDPTarget(t)}, 1).Start(ctx)
+	waitServed(t, s, "dns")
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	us := udp.NewServer(time.Minute)
+	if err := us.Serve(pc, tunnel.NewDatagramController(log, s, "dns")); err != nil {
+		t.Fatal(err)
+	}
+	defer us.Close()
+	public, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer public.Close()
+
+	// packets may be lost on a loaded machine
+	buf := make([]byte, 64<<10)
+	var reply string
+	for i := 0; i < 50 && reply == ""; i++ {
+		public.Write([]byte("ping"))
+		public.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		if n, err := public.Read(buf); err == nil {
+			reply = string(buf[:n])
+		}
+	}
+	if reply != "got ping" {
+		t.Fatalf("expected the reply of the target, got %q", reply)
+	}
+
+	// packets larger than a read of a TCP connection and sent back to back are not split nor merged
+	large := bytes.Repeat([]byte("x"), 4000)
+	public.Write(large)
+	public.Write([]byte("small"))
+	public.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for _, expected := range [][]byte{append([]byte("got "), large...), []byte("got small")} {
+		n, err := public.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf[:n], expected) {
+			t.Fatalf("expected a packet of %d bytes, got %d", len(expected), n)
+		}
+	}
+}
+
+func TestRouter_LocalForward(t *testing.T) {
+	log := zap.NewNop()
+	destination := startReplyTarget(t)
+	destinations, err := allow.Parse([]string{destination})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tunnel.NewService(log, tunnel.WithDial(destinations))
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the router serves no tunnel, it only forwards local connections
+	r := NewRouter(log, tc, nil, 1)
+	go r.Start(ctx)
+	waitConnected(t, r)
+
+	forward := func(destination string) string {
+		ln, err := nettest.NewLocalListener("tcp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go r.Forward(ctx, ln, destination)
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		conn.Write([]byte("ping"))
+		conn.(*net.TCPConn).CloseWrite()
+		reply, _ := io.ReadAll(conn)
+		return string(reply)
+	}
+	if reply := forward(destination); reply != "got ping" {
+		t.Fatalf("expected the reply of the destination, got %q", reply)
+	}
+	if reply := forward("192.0.2.1:5432"); reply != "" {
+		t.Fatalf("expected the connection to a destination not allowed to be closed, got %q", reply)
+	}
+	if stats := s.Stats(); stats.Dialed != 1 || stats.DialFailed != 1 {
+		t.Fatalf("expected a dialed and a refused destination, got %d and %d", stats.Dialed, stats.DialFailed)
+	}
+}
+
+func TestRouter_SOCKS(t *testing.T) {
+	log := zap.NewNop()
+	destination := startReplyTarget(t)
+	closed, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	destinations, err := allow.Parse([]string{destination, closed.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tunnel.NewService(log)
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := NewRouter(log, tc, nil, 1, WithDestinations(map[string]*allow.List{"socks": destinations}))
+	go r.Start(ctx)
+	waitConnected(t, r)
+
+	ln, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c := tunnel.NewController(log, s, "socks", tunnel.WithSOCKS(map[string]string{"alice": "secret"}))
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go c.Handle(ctx, conn)
+		}
+	}()
+
+	connect := func(auth *proxy.Auth, destination string) (string, error) {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		dialer, err := proxy.SOCKS5("tcp", ln.Addr().String(), auth, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the handshake runs on our own connection so that its write side can be shut down
+		d := dialer.(interface {
+			DialWithConn(context.Context, net.Conn, string, string) (net.Addr, error)
+		})
+		if _, err := d.DialWithConn(ctx, conn, "tcp", destination); err != nil {
+			return "", err
+		}
+		conn.Write([]byte("ping"))
+		conn.(*net.TCPConn).CloseWrite()
+		reply, _ := io.ReadAll(conn)
+		return string(reply), nil
+	}
+	alice := &proxy.Auth{User: "alice", Password: "secret"}
+	if reply, err := connect(alice, destination); err != nil || reply != "got ping" {
+		t.Fatalf("expected the reply of the destination, got %q: %v", reply, err)
+	}
+	if _, err := connect(alice, "192.0.2.1:5432"); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("expected the destination to be refused by the client, got %v", err)
+	}
+	if _, err := connect(alice, closed.Addr().String()); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("expected the destination to refuse the connection, got %v", err)
+	}
+	if _, err := connect(&proxy.Auth{User: "alice", Password: "wrong"}, destination); err == nil {
+		t.Fatal("expected a wrong password to be refused")
+	}
+}
+
+func TestRouter_HTTPProxy(t *testing.T) {
+	log := zap.NewNop()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("got " + req.URL.Path))
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+	closed, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	destinations, err := allow.Parse([]string{plain.Listener.Addr().String(), secure.Listener.Addr().String(), closed.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tunnel.NewService(log)
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := NewRouter(log, tc, nil, 1, WithDestinations(map[string]*allow.List{"proxy": destinations}))
+	go r.Start(ctx)
+	waitConnected(t, r)
+
+	ln, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c := tunnel.NewController(log, s, "proxy", tunnel.WithHTTPProxy(map[string]string{"alice": "secret"}))
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go c.Handle(ctx, conn)
+		}
+	}()
+
+	get := func(user *url.Userinfo, target string) (int, string) {
+		transport := secure.Client().Transport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(&url.URL{Scheme: "http", User: user, Host: ln.Addr().String()})
+		client := &http.Client{Transport: transport, Timeout: 2 * time.Second}
+		resp, err := client.Get(target)
+		if err != nil {
+			return 0, err.Error()
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	alice := url.UserPassword("alice", "secret")
+	if status, body := get(alice, plain.URL+"/plain"); status != http.StatusOK || body != "got /plain" {
+		t.Fatalf("expected the answer of the plain destination, got %d %q", status, body)
+	}
+	if status, body := get(alice, secure.URL+"/connect"); status != http.StatusOK || body != "got /connect" {
+		t.Fatalf("expected the answer of the destination through CONNECT, got %d %q", status, body)
+	}
+	if status, _ := get(alice, "http://192.0.2.1:5432/"); status != http.StatusForbidden {
+		t.Fatalf("expected a destination not allowed to be forbidden, got %d", status)
+	}
+	// the client wraps the error of its dial
+	if status, _ := get(alice, "http://"+closed.Addr().String()+"/"); status != http.StatusBadGateway {
+		t.Fatalf("expected a destination refusing the connection to be a bad gateway, got %d", status)
+	}
+	if status, _ := get(url.UserPassword("alice", "wrong"), plain.URL); status != http.StatusProxyAuthRequired {
+		t.Fatalf("expected a wrong password to be refused, got %d", status)
+	}
+}
+
+func TestRouter_Vhost(t *testing.T) {
+	log := zap.NewNop()
+	target := func(name string) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(name + " " + req.Host))
+		}))
+		t.Cleanup(srv.Close)
+		return srv.Listener.Addr().String()
+	}
+	s := tunnel.NewService(log)
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	targets := map[string]string{"app.example.com": target("app"), "*.example.com": target("wildcard"), "down.example.com": "127.0.0.1:1"}
+	r := NewRouter(log, tc, targets, 1)
+	go r.Start(ctx)
+	waitConnected(t, r)
+
+	ln, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	page := template.Must(template.New("page").Parse("custom {{.Status}} {{.Host}}"))
+	c := tunnel.NewVhostController(log, s, tunnel.WithErrorPage(page))
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go c.Handle(ctx, conn)
+		}
+	}()
+
+	// the requests share a keep-alive connection, each is routed by its own Host
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	br := bufio.NewReader(conn)
+	get := func(host string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		if err := req.Write(conn); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.ReadResponse(br, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	tests := []struct {
+		host   string
+		status int
+		body   string
+	}{
+		{"app.example.com", http.StatusOK, "app app.example.com"},
+		{"api.example.com", http.StatusOK, "wildcard api.example.com"},
+		{"unknown.org", http.StatusNotFound, "custom 404 unknown.org"},
+		{"APP.example.com:80", http.StatusOK, "app APP.example.com:80"},
+		{"down.example.com", http.StatusBadGateway, "custom 502 down.example.com"},
+		{"app.example.com", http.StatusOK, "app app.example.com"},
+	}
+	for _, tt := range tests {
+		if status, body := get(tt.host); status != tt.status || body != tt.body {
+			t.Fatalf("expected %d %q for %s, got %d %q", tt.status, tt.body, tt.host, status, body)
+		}
+	}
+}
+
+func TestRouter_SlowReader(t *testing.T) {
+	// the bulk target sends more than the window to a public connection that never reads
+	bulk, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bulk.Close()
+	go func() {
+		for {
+			conn, err := bulk.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write(make([]byte, 1<<20))
+			}()
+		}
+	}()
+
+	log := zap.NewNop()
+	s := tunnel.NewService(log, tunnel.WithWindow(16<<10))
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	targets := map[string]string{"a": startTarget(t, "a"), "bulk": bulk.Addr().String()}
+	go NewRouter(log, tc, targets, 1, WithWindow(16<<10)).Start(ctx)
+
+	a := tunnel.NewController(log, s, "a")
+	waitServed(t, s, "a", "bulk")
+	if got := roundTrip(t, a); got != "a" {
+		t.Fatalf("expected reply from a, got %s", got)
+	}
+	public, conn := net.Pipe()
+	defer public.Close()
+	go tunnel.NewController(log, s, "bulk").Handle(ctx, conn)
+	time.Sleep(100 * time.Millisecond)
+
+	if got := roundTrip(t, a); got != "a" {
+		t.Fatalf("expected reply from a while bulk is stalled, got %s", got)
+	}
+}
+
+func TestRouter_InteractiveDuringBulk(t *testing.T) {
+	bulk, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bulk.Close()
+	go func() {
+		conn, err := bulk.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write(make([]byte, 4<<20))
+	}()
+
+	log := zap.NewNop()
+	s := tunnel.NewService(log)
+	tc, _ := startServer(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	targets := map[string]string{"a": startTarget(t, "a"), "bulk": bulk.Addr().String()}
+	go NewRouter(log, tc, targets, 1).Start(ctx)
+
+	a := tunnel.NewController(log, s, "a")
+	waitServed(t, s, "a", "bulk")
+	roundTrip(t, a)
+	public, conn := net.Pipe()
+	defer public.Close()
+	go tunnel.NewController(log, s, "bulk").Handle(ctx, conn)
+	received := make(chan int64)
+	go func() {
+		// net.Pipe has no half-close, so read the expected bytes rather than until the target closes
+		n, _ := io.CopyN(io.Discard, public, 4<<20)
+		received <- n
+	}()
+
+	// round trips of the interactive connection do not queue behind the bulk transfer
+	for i := 0; i < 10; i++ {
+		start := time.Now()
+		if got := roundTrip(t, a); got != "a" {
+			t.Fatalf("expected reply from a, got %s", got)
+		}
+		if d := time.Since(start); d > 250*time.Millisecond {
+			t.Fatalf("round trip took %s during the bulk transfer", d)
+		}
+	}
+	if n := <-received; n != 4<<20 {
+		t.Fatalf("expected the whole bulk transfer, got %d bytes", n)
+	}
+}
+
+func TestRouter_StreamPerConnection(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		server, client bool
+	}{
+		{"both", true, true},
+		{"server only", true, false},
+		{"client only", false, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zap.NewNop()
+			s := tunnel.NewService(log, tunnel.WithStreamPerConnection(tt.server))
+			tc, _ := startServer(t, s)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			r := NewRouter(log, tc, map[string]string{"a": startTarget(t, "a")}, 1, WithStreamPerConnection(tt.client))
+			go r.Start(ctx)
+			waitServed(t, s, "a")
+
+			c := tunnel.NewController(log, s, "a")
+			for i := 0; i < 3; i++ {
+				if got := roundTrip(t, c); got != "a" {
+					t.Fatalf("expected reply from a, got %s", got)
+				}
+			}
+			if got := s.Stats().Opened; got != 3 {
+				t.Fatalf("expected 3 opened connections, got %d", got)
+			}
+			// the client forgets connections once the public side closes them
+			active := func() int {
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				return len(r.connections)
+			}
+			for i := 0; i < 50 && active() > 0; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if n := active(); n != 0 {
+				t.Fatalf("expected connections to be closed, %d left", n)
+			}
+		})
+	}
+}
+
+// zeros is an endless source of data for throughput benchmarks
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// benchmarkThroughput downloads through the full server and client path on parallel public connections
+func benchmarkThroughput(b *testing.B, streams, connections int) {
+	target, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, zeros{})
+			}()
+		}
+	}()
+
+	log := zap.NewNop()
+	s := tunnel.NewService(log)
+	grpcLn, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		b.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	tunnelv1.RegisterTunnelServiceServer(gs, s)
+	go gs.Serve(grpcLn)
+	defer gs.Stop()
+
+	var clients []tunnelv1.TunnelServiceClient
+	for i := 0; i < connections; i++ {
+		cc, err := grpc.Dial(grpcLn.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer cc.Close()
+		clients = append(clients, tunnelv1.NewTunnelServiceClient(cc))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := NewRouter(log, clients[0], map[string]string{"a": target.Addr().String()}, 1, WithClients(clients[1:]...), WithStreams(streams))
+	go r.Start(ctx)
+
+	public, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer public.Close()
+	c := tunnel.NewController(log, s, "a")
+	go func() {
+		for {
+			conn, err := public.Accept()
+			if err != nil {
+				return
+			}
+			go c.Handle(ctx, conn)
+		}
+	}()
+	for i := 0; r.State() != StateConnected; i++ {
+		if i == 100 {
+			b.Fatal("client not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	const chunk = 64 << 10
+	b.SetBytes(chunk)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		conn, err := net.Dial("tcp", public.Addr().String())
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, chunk)
+		for pb.Next() {
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkRouter_Throughput(b *testing.B) {
+	for _, bm := range []struct {
+		name                 string
+		streams, connections int
+	}{
+		{"single", 1, 1},
+		{"streams=4", 4, 1},
+		{"streams=4,connections=4", 4, 4},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			benchmarkThroughput(b, bm.streams, bm.connections)
+		})
+	}
+}
+
+func TestFailureOf(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+	tests := []struct {
+		err      error
+		expected tunnelv1.Failure
+	}{
+		{fmt.Errorf("%w: db.internal:22", allow.ErrDenied), tunnelv1.Failure_DENIED},
+		{fmt.Errorf("cannot connect to target: %w", refused), tunnelv1.Failure_REFUSED},
+		{fmt.Errorf("cannot connect to target: %w", &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "db.internal"}}), tunnelv1.Failure_UNREACHABLE},
+		{fmt.Errorf("unknown tunnel %q", "web"), tunnelv1.Failure_OTHER},
+	}
+	for _, tt := range tests {
+		if got := failureOf(tt.err); got != tt.expected {
+			t.Fatalf("%v: expected %s, got %s", tt.err, tt.expected, got)
+		}
+	}
 }
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v2"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"google.golang.org/grpc"
+)
+
+// clientV2 speaks the tunnel.v2 API to the server on behalf of the Router
+type clientV2 struct {
+	c tunnelv2.TunnelServiceClient
+}
+
+// NewClientV2 returns a client of the tunnel.v2 API of the server, translating the messages of the Router
+func NewClientV2(cc grpc.ClientConnInterface) tunnelv1.TunnelServiceClient {
+	return clientV2{c: tunnelv2.NewTunnelServiceClient(cc)}
+}
+
+func (c clientV2) Tunnel(ctx context.Context, opts ...grpc.CallOption) (tunnelv1.TunnelService_TunnelClient, error) {
+	stream, err := c.c.Tunnel(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return streamV2{stream}, nil
+}
+
+func (c clientV2) Connect(ctx context.Context, opts ...grpc.CallOption) (tunnelv1.TunnelService_ConnectClient, error) {
+	stream, err := c.c.Connect(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return streamV2{stream}, nil
+}
+
+// clientStreamV2 is a Tunnel or Connect stream of tunnel.v2
+type clientStreamV2 interface {
+	Send(*tunnelv2.TunnelRequest) error
+	Recv() (*tunnelv2.TunnelResponse, error)
+	grpc.ClientStream
+}
+
+// streamV2 translates the messages of a tunnel.v2 stream
+type streamV2 struct {
+	clientStreamV2
+}
+
+func (s streamV2) Send(req *tunnelv1.TunnelRequest) error {
+	msg, err := protocol.RequestToV2(req)
+	if err != nil {
+		return err
+	}
+	return s.clientStreamV2.Send(msg)
+}
+
+func (s streamV2) Recv() (*tunnelv1.TunnelResponse, error) {
+	msg, err := s.clientStreamV2.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return protocol.ResponseFromV2(msg)
+}
@@ -0,0 +1,185 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Algorithm compresses the data of tunnelled connections
+type Algorithm string
+
+const (
+	None Algorithm = "none"
+	Gzip Algorithm = "gzip"
+	Zstd Algorithm = "zstd"
+)
+
+// Algorithms lists the supported compression algorithms
+var Algorithms = []Algorithm{None, Gzip, Zstd}
+
+// DefaultThreshold is the size below which data is sent as is, compressing it would not pay off
+const DefaultThreshold = 256
+
+// maxWindow bounds the memory a peer can have the zstd decoder allocate
+const maxWindow = 8 << 20
+
+// ErrTooLarge is returned when data decompresses to more than the receiver accepts
+var ErrTooLarge = errors.New("decompressed data too large")
+
+// ParseAlgorithm returns the algorithm with the given name
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for _, a := range Algorithms {
+		if string(a) == name {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("unknown compression %q, expected one of %v", name, Algorithms)
+}
+
+// Capability is the name announcing support of the algorithm in the hello exchange
+func (a Algorithm) Capability() string {
+	return "compression-" + string(a)
+}
+
+// Proto returns the algorithm as set on data frames
+func (a Algorithm) Proto() tunnelv1.Compression {
+	switch a {
+	case Gzip:
+		return tunnelv1.Compression_GZIP
+	case Zstd:
+		return tunnelv1.Compression_ZSTD
+	}
+	return tunnelv1.Compression_NONE
+}
+
+// FromProto returns the algorithm a data frame is compressed with
+func FromProto(c tunnelv1.Compression) (Algorithm, error) {
+	switch c {
+	case tunnelv1.Compression_NONE:
+		return None, nil
+	case tunnelv1.Compression_GZIP:
+		return Gzip, nil
+	case tunnelv1.Compression_ZSTD:
+		return Zstd, nil
+	}
+	return "", fmt.Errorf("unknown compression %v", c)
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		w, _ := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+		return w
+	}}
+	gzipReaders = sync.Pool{}
+	zstdEncoder *zstd.Encoder
+	zstdReaders = sync.Pool{New: func() any {
+		d, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true), zstd.WithDecoderMaxWindow(maxWindow))
+		return d
+	}}
+)
+
+func init() {
+	// EncodeAll is safe for concurrent use
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+}
+
+// Encoder compresses data with an algorithm once it reaches a threshold, the zero value sends data as is
+type Encoder struct {
+	Algorithm Algorithm
+	Threshold int
+	// Counter, if set, counts the bytes compressed
+	Counter *Counter
+}
+
+// Encode returns data compressed and the algorithm it was compressed with, None if it is sent as is because it is
+// too small or does not compress
+func (e Encoder) Encode(data []byte) ([]byte, Algorithm) {
+	if len(data) < e.Threshold {
+		return data, None
+	}
+	var compressed []byte
+	switch e.Algorithm {
+	case Gzip:
+		var buf bytes.Buffer
+		w := gzipWriters.Get().(*gzip.Writer)
+		w.Reset(&buf)
+		w.Write(data)
+		w.Close()
+		gzipWriters.Put(w)
+		compressed = buf.Bytes()
+	case Zstd:
+		compressed = zstdEncoder.EncodeAll(data, nil)
+	default:
+		return data, None
+	}
+	if len(compressed) >= len(data) {
+		return data, None
+	}
+	if e.Counter != nil {
+		e.Counter.Add(len(data), len(compressed))
+	}
+	return compressed, e.Algorithm
+}
+
+// Decode returns data compressed with the given algorithm, refusing to inflate it beyond limit bytes
+func Decode(a Algorithm, data []byte, limit int) ([]byte, error) {
+	switch a {
+	case None:
+		return data, nil
+	case Gzip:
+		r, _ := gzipReaders.Get().(*gzip.Reader)
+		var err error
+		if r == nil {
+			r, err = gzip.NewReader(bytes.NewReader(data))
+		} else {
+			err = r.Reset(bytes.NewReader(data))
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReaders.Put(r)
+		return inflate(r, limit)
+	case Zstd:
+		d := zstdReaders.Get().(*zstd.Decoder)
+		if err := d.Reset(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		defer zstdReaders.Put(d)
+		return inflate(d, limit)
+	}
+	return nil, fmt.Errorf("unknown compression %q", a)
+}
+
+func inflate(r io.Reader, limit int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// Counter counts the bytes of the data compressed, to report the compression ratio
+type Counter struct {
+	raw, compressed atomic.Uint64
+}
+
+// Add counts raw bytes compressed to compressed bytes
+func (c *Counter) Add(raw, compressed int) {
+	c.raw.Add(uint64(raw))
+	c.compressed.Add(uint64(compressed))
+}
+
+// Load returns the bytes counted before and after compression
+func (c *Counter) Load() (raw, compressed uint64) {
+	return c.raw.Load(), c.compressed.Load()
+}
@@ -0,0 +1,55 @@
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	data := bytes.Repeat([]byte("compressible "), 100)
+	for _, a := range []Algorithm{Gzip, Zstd} {
+		var c Counter
+		e := Encoder{Algorithm: a, Threshold: DefaultThreshold, Counter: &c}
+		compressed, used := e.Encode(data)
+		if used != a || len(compressed) >= len(data) {
+			t.Fatalf("%s: expected the data to be compressed, got %d bytes with %s", a, len(compressed), used)
+		}
+		decoded, err := Decode(a, compressed, len(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Fatalf("%s: expected the data back", a)
+		}
+		if raw, n := c.Load(); raw != uint64(len(data)) || n != uint64(len(compressed)) {
+			t.Fatalf("%s: expected %d bytes compressed to %d, counted %d to %d", a, len(data), len(compressed), raw, n)
+		}
+		if _, err := Decode(a, compressed, len(data)-1); !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("%s: expected %v, got %v", a, ErrTooLarge, err)
+		}
+
+		// small or incompressible data is sent as is
+		if out, used := e.Encode(data[:DefaultThreshold-1]); used != None || len(out) != DefaultThreshold-1 {
+			t.Fatalf("%s: expected data below the threshold as is, got %s", a, used)
+		}
+		if _, used := e.Encode(compressed); used != None {
+			t.Fatalf("%s: expected compressed data as is, got %s", a, used)
+		}
+	}
+
+	if out, used := (Encoder{}).Encode(data); used != None || len(out) != len(data) {
+		t.Fatalf("expected the zero encoder to send data as is, got %s", used)
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for _, a := range Algorithms {
+		if got, err := ParseAlgorithm(string(a)); err != nil || got != a {
+			t.Fatalf("expected %s, got %s %v", a, got, err)
+		}
+	}
+	if _, err := ParseAlgorithm("lz4"); err == nil {
+		t.Fatal("expected an unknown algorithm to be refused")
+	}
+}
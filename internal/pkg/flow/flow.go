@@ -0,0 +1,202 @@
+package flow
+
+import (
+	"errors"
+	"sync"
+)
+
+// DefaultWindow is the number of bytes a peer may send on a connection before it is granted more
+const DefaultWindow = 256 << 10
+
+// ErrWindowExceeded is returned when a peer sends more data than it was granted
+var ErrWindowExceeded = errors.New("flow control window exceeded")
+
+// Window is the credit available to send data on a connection, it is used by a single sender
+type Window struct {
+	mu     sync.Mutex
+	credit int
+	ready  chan struct{}
+}
+
+func NewWindow(size int) *Window {
+	return &Window{credit: size, ready: make(chan struct{}, 1)}
+}
+
+// Add grants n more bytes of credit
+func (w *Window) Add(n int) {
+	w.mu.Lock()
+	w.credit += n
+	w.mu.Unlock()
+	signal(w.ready)
+}
+
+// Take takes up to n bytes of credit without waiting, it returns 0 when no credit is left
+func (w *Window) Take(n int) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if n > w.credit {
+		n = w.credit
+	}
+	w.credit -= n
+	return n
+}
+
+// TakeAll takes n bytes of credit without waiting if that much is left, so that a packet is sent whole or not at all
+func (w *Window) TakeAll(n int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if n > w.credit {
+		return false
+	}
+	w.credit -= n
+	return true
+}
+
+// Ready is signalled when credit is added
+func (w *Window) Ready() <-chan struct{} {
+	return w.ready
+}
+
+// Buffer queues the data received on a connection until it is consumed, holding at most the window granted to the peer
+type Buffer struct {
+	mu       sync.Mutex
+	queue    [][]byte
+	size     int
+	max      int
+	closed   bool
+	consumed int
+	// received is the offset following the data received, released the bytes consumed and granted to the peer
+	received int64
+	released int64
+	// end is the offset the peer closed its side at, negative until it does
+	end     int64
+	ready   chan struct{}
+	updates chan struct{}
+}
+
+func NewBuffer(size int) *Buffer {
+	return &Buffer{max: size, end: -1, ready: make(chan struct{}, 1), updates: make(chan struct{}, 1)}
+}
+
+// Push queues data following what was received so far without waiting, data pushed after Close is dropped
+func (b *Buffer) Push(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.push(b.received, data)
+}
+
+// PushAt queues data starting at offset seq of the connection. Bytes already received are skipped and data past
+// a gap is dropped, the peer sends it again once its stream is resumed.
+func (b *Buffer) PushAt(seq int64, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.push(seq, data)
+}
+
+func (b *Buffer) push(seq int64, data []byte) error {
+	if b.closed || seq > b.received {
+		return nil
+	}
+	if skip := b.received - seq; skip > 0 {
+		if skip >= int64(len(data)) {
+			return nil
+		}
+		data = data[skip:]
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if b.size+len(data) > b.max {
+		return ErrWindowExceeded
+	}
+	b.queue = append(b.queue, data)
+	b.size += len(data)
+	b.received += int64(len(data))
+	if b.end >= 0 && b.received >= b.end {
+		b.closed = true
+	}
+	signal(b.ready)
+	return nil
+}
+
+// Close marks the end of the data, Pop returns what is queued before reporting it
+func (b *Buffer) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	signal(b.ready)
+}
+
+// CloseAt marks the end of the data at offset end, once the data before it is received
+func (b *Buffer) CloseAt(end int64) {
+	b.mu.Lock()
+	if b.received >= end {
+		b.closed = true
+	} else {
+		b.end = end
+	}
+	b.mu.Unlock()
+	signal(b.ready)
+}
+
+// Pop waits for queued data, it returns false once the buffer is closed and drained or done is closed
+func (b *Buffer) Pop(done <-chan struct{}) ([]byte, bool) {
+	for {
+		b.mu.Lock()
+		if len(b.queue) > 0 {
+			data := b.queue[0]
+			b.queue[0] = nil
+			b.queue = b.queue[1:]
+			b.size -= len(data)
+			b.consumed += len(data)
+			// granting credit in batches of half the window keeps the peer sending without an update per read
+			if b.consumed >= b.max/2 {
+				signal(b.updates)
+			}
+			b.mu.Unlock()
+			return data, true
+		}
+		closed := b.closed
+		b.mu.Unlock()
+		if closed {
+			return nil, false
+		}
+		select {
+		case <-b.ready:
+		case <-done:
+			return nil, false
+		}
+	}
+}
+
+// Updates is signalled once enough data is consumed to grant the peer more credit
+func (b *Buffer) Updates() <-chan struct{} {
+	return b.updates
+}
+
+// Release returns the bytes consumed since the last call, to be granted to the peer
+func (b *Buffer) Release() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.consumed
+	b.consumed = 0
+	b.released += int64(n)
+	return n
+}
+
+// Position returns the offset following the data received and the bytes consumed, releasing those to the peer.
+// A resumed peer sends again the data after the first and restores the credit of the second.
+func (b *Buffer) Position() (received, consumed int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.released += int64(b.consumed)
+	b.consumed = 0
+	return b.received, b.released
+}
+
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,114 @@
+package flow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow_Take(t *testing.T) {
+	w := NewWindow(10)
+	if n := w.Take(4); n != 4 {
+		t.Fatalf("expected 4, got %d", n)
+	}
+	if n := w.Take(10); n != 6 {
+		t.Fatalf("expected the remaining 6, got %d", n)
+	}
+	if n := w.Take(1); n != 0 {
+		t.Fatalf("expected no credit, got %d", n)
+	}
+	w.Add(3)
+	select {
+	case <-w.Ready():
+	default:
+		t.Fatal("expected window to be ready")
+	}
+	if n := w.Take(5); n != 3 {
+		t.Fatalf("expected 3, got %d", n)
+	}
+}
+
+func TestWindow_TakeAll(t *testing.T) {
+	w := NewWindow(10)
+	if !w.TakeAll(6) {
+		t.Fatal("expected 6 bytes of credit")
+	}
+	if w.TakeAll(5) {
+		t.Fatal("expected too little credit for 5 bytes")
+	}
+	if n := w.Take(10); n != 4 {
+		t.Fatalf("expected the credit left untouched, got %d", n)
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	b := NewBuffer(8)
+	if err := b.Push([]byte("abcd")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Push([]byte("efghi")); err != ErrWindowExceeded {
+		t.Fatalf("expected %v, got %v", ErrWindowExceeded, err)
+	}
+	if err := b.Push([]byte("efgh")); err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+	b.Push([]byte("dropped"))
+
+	done := make(chan struct{})
+	for _, want := range []string{"abcd", "efgh"} {
+		data, ok := b.Pop(done)
+		if !ok || string(data) != want {
+			t.Fatalf("expected %s, got %s", want, data)
+		}
+	}
+	if _, ok := b.Pop(done); ok {
+		t.Fatal("expected closed buffer to be drained")
+	}
+	select {
+	case <-b.Updates():
+	default:
+		t.Fatal("expected an update after consuming half the window")
+	}
+	if n := b.Release(); n != 8 {
+		t.Fatalf("expected 8 consumed bytes, got %d", n)
+	}
+	if n := b.Release(); n != 0 {
+		t.Fatalf("expected consumed bytes to reset, got %d", n)
+	}
+}
+
+func TestBuffer_PopDone(t *testing.T) {
+	b := NewBuffer(8)
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(done)
+	}()
+	if _, ok := b.Pop(done); ok {
+		t.Fatal("expected pop to stop when done")
+	}
+}
+
+func TestBuffer_PushAt(t *testing.T) {
+	b := NewBuffer(16)
+	b.PushAt(0, []byte("abcd"))
+	// a frame sent again overlaps what was received, a frame past a gap waits to be sent again
+	b.PushAt(2, []byte("cdef"))
+	b.PushAt(8, []byte("ijkl"))
+	b.CloseAt(8)
+	b.PushAt(6, []byte("gh"))
+
+	done := make(chan struct{})
+	for _, want := range []string{"abcd", "ef", "gh"} {
+		data, ok := b.Pop(done)
+		if !ok || string(data) != want {
+			t.Fatalf("expected %s, got %s", want, data)
+		}
+	}
+	if _, ok := b.Pop(done); ok {
+		t.Fatal("expected buffer to be closed at offset 8")
+	}
+	if received, consumed := b.Position(); received != 8 || consumed != 8 {
+		t.Fatalf("expected position 8/8, got %d/%d", received, consumed)
+	}
+}
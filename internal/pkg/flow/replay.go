@@ -0,0 +1,98 @@
+package flow
+
+import "sync"
+
+// replayChunk bounds the size of the frames data is sent again in
+const replayChunk = 16 << 10
+
+// replayCompact is the size of consumed data past which it is dropped from the buffer, if it is at least half of it
+const replayCompact = 64 << 10
+
+// Replay keeps the data sent on a connection until the peer has consumed it, so it can be sent again once a broken
+// stream is resumed. The credit granted by the peer bounds it to the window.
+type Replay struct {
+	mu sync.Mutex
+	// acked is the offset of the first byte kept, data[off:] is the data kept
+	acked  int64
+	data   []byte
+	off    int
+	closed bool
+}
+
+func NewReplay() *Replay {
+	return &Replay{}
+}
+
+// Append records data sent to the peer and returns the offset of its first byte
+func (r *Replay) Append(data []byte) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seq := r.acked + int64(len(r.data)-r.off)
+	r.data = append(r.data, data...)
+	return seq
+}
+
+// Close records the end of the data and returns its offset
+func (r *Replay) Close() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return r.acked + int64(len(r.data)-r.off)
+}
+
+// Ack drops the next n bytes, consumed by the peer
+func (r *Replay) Ack(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trim(n)
+}
+
+// AckTo drops the data before offset and returns the number of bytes it acknowledged
+func (r *Replay) AckTo(offset int64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if offset <= r.acked {
+		return 0
+	}
+	return r.trim(int(offset - r.acked))
+}
+
+// trim drops the consumed data by moving past it, the buffer is only compacted once most of it is consumed
+func (r *Replay) trim(n int) int {
+	if kept := len(r.data) - r.off; n > kept {
+		n = kept
+	}
+	r.acked += int64(n)
+	r.off += n
+	switch {
+	case r.off == len(r.data):
+		r.data, r.off = r.data[:0], 0
+	case r.off >= replayCompact && r.off >= len(r.data)/2:
+		r.data = r.data[:copy(r.data, r.data[r.off:])]
+		r.off = 0
+	}
+	return n
+}
+
+// From returns the data kept from offset on in chunks, the offset of its end and whether the data ends there
+func (r *Replay) From(offset int64) ([][]byte, int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	end := r.acked + int64(len(r.data)-r.off)
+	if offset < r.acked {
+		offset = r.acked
+	}
+	if offset > end {
+		offset = end
+	}
+	var chunks [][]byte
+	for data := r.data[r.off+int(offset-r.acked):]; len(data) > 0; {
+		n := len(data)
+		if n > replayChunk {
+			n = replayChunk
+		}
+		chunks = append(chunks, append([]byte(nil), data[:n]...))
+		data = data[n:]
+	}
+	return chunks, end, r.closed
+}
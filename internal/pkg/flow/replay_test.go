@@ -0,0 +1,66 @@
+package flow
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	r := NewReplay()
+	if seq := r.Append([]byte("abcd")); seq != 0 {
+		t.Fatalf("expected offset 0, got %d", seq)
+	}
+	if seq := r.Append([]byte("efgh")); seq != 4 {
+		t.Fatalf("expected offset 4, got %d", seq)
+	}
+	r.Ack(2)
+	if n := r.AckTo(3); n != 1 {
+		t.Fatalf("expected 1 acknowledged byte, got %d", n)
+	}
+	if n := r.AckTo(1); n != 0 {
+		t.Fatalf("expected nothing acknowledged again, got %d", n)
+	}
+	chunks, end, closed := r.From(6)
+	if len(chunks) != 1 || string(chunks[0]) != "gh" || end != 8 || closed {
+		t.Fatalf("unexpected replay %q %d %v", chunks, end, closed)
+	}
+	if end := r.Close(); end != 8 {
+		t.Fatalf("expected end at 8, got %d", end)
+	}
+	if chunks, _, closed = r.From(0); string(chunks[0]) != "defgh" || !closed {
+		t.Fatalf("unexpected replay %q %v", chunks, closed)
+	}
+}
+
+func TestReplay_Chunks(t *testing.T) {
+	r := NewReplay()
+	data := bytes.Repeat([]byte("x"), replayChunk*2+1)
+	r.Append(data)
+	chunks, _, _ := r.From(0)
+	if len(chunks) != 3 || !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Fatalf("expected the data in 3 chunks, got %d", len(chunks))
+	}
+}
+
+func TestReplay_Compact(t *testing.T) {
+	r := NewReplay()
+	var sent []byte
+	for i := 0; i < 64; i++ {
+		data := bytes.Repeat([]byte{byte(i)}, 4<<10)
+		r.Append(data)
+		sent = append(sent, data...)
+		// the peer consumes all but the last 3 KiB
+		r.AckTo(int64(len(sent) - 3<<10))
+		if r.off >= replayCompact && r.off >= len(r.data)/2 {
+			t.Fatalf("expected the consumed data to be compacted, %d of %d bytes are", r.off, len(r.data))
+		}
+	}
+	chunks, end, _ := r.From(0)
+	if end != int64(len(sent)) || !bytes.Equal(bytes.Join(chunks, nil), sent[len(sent)-3<<10:]) {
+		t.Fatalf("unexpected data kept after compaction, end %d", end)
+	}
+	r.Ack(3 << 10)
+	if len(r.data) != 0 || r.off != 0 {
+		t.Fatalf("expected the buffer to be reset once all is consumed, got %d bytes at %d", len(r.data), r.off)
+	}
+}
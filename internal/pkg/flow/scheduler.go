@@ -0,0 +1,126 @@
+package flow
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultQuantum is the number of bytes a connection may send in its turn before the next connection is served
+const DefaultQuantum = 4 << 10
+
+// frameOverhead is counted on top of the payload of every frame so control frames are not free
+const frameOverhead = 32
+
+// Scheduler decides which connection sends the next frame on a stream shared by many connections.
+// Connections of the highest priority class with queued frames are served first, connections of the same
+// class take turns in deficit round robin so a bulk transfer cannot starve small interactive flows.
+// Frames of a connection are sent in the order they are pushed.
+type Scheduler[T any] struct {
+	mu      sync.Mutex
+	quantum int
+	queues  map[string]*queue[T]
+	// classes are ordered by descending priority
+	classes []*class[T]
+	ready   chan struct{}
+}
+
+type class[T any] struct {
+	priority int
+	// active are the connections with queued frames, the first one is taking its turn
+	active []*queue[T]
+}
+
+type queue[T any] struct {
+	id      string
+	class   *class[T]
+	items   []item[T]
+	deficit int
+	// turn is set while the connection is taking its turn
+	turn bool
+}
+
+type item[T any] struct {
+	v    T
+	cost int
+}
+
+func NewScheduler[T any](quantum int) *Scheduler[T] {
+	if quantum < 1 {
+		quantum = DefaultQuantum
+	}
+	return &Scheduler[T]{quantum: quantum, queues: make(map[string]*queue[T]), ready: make(chan struct{}, 1)}
+}
+
+// Push queues a frame of size bytes for a connection without waiting.
+// The priority class is that of the connection's first queued frame.
+func (s *Scheduler[T]) Push(id string, priority, size int, v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, ok := s.queues[id]
+	if !ok {
+		q = &queue[T]{id: id, class: s.class(priority)}
+		s.queues[id] = q
+		q.class.active = append(q.class.active, q)
+	}
+	q.items = append(q.items, item[T]{v: v, cost: size + frameOverhead})
+	signal(s.ready)
+}
+
+// class returns the class of a priority, adding it if needed
+func (s *Scheduler[T]) class(priority int) *class[T] {
+	i := sort.Search(len(s.classes), func(i int) bool { return s.classes[i].priority <= priority })
+	if i < len(s.classes) && s.classes[i].priority == priority {
+		return s.classes[i]
+	}
+	c := &class[T]{priority: priority}
+	s.classes = append(s.classes, nil)
+	copy(s.classes[i+1:], s.classes[i:])
+	s.classes[i] = c
+	return c
+}
+
+// Pop waits for the next frame to send, it returns false if done is closed first
+func (s *Scheduler[T]) Pop(done <-chan struct{}) (T, bool) {
+	for {
+		s.mu.Lock()
+		if v, ok := s.next(); ok {
+			s.mu.Unlock()
+			return v, true
+		}
+		s.mu.Unlock()
+		select {
+		case <-s.ready:
+		case <-done:
+			var zero T
+			return zero, false
+		}
+	}
+}
+
+func (s *Scheduler[T]) next() (T, bool) {
+	for _, c := range s.classes {
+		for len(c.active) > 0 {
+			q := c.active[0]
+			if !q.turn {
+				q.turn = true
+				q.deficit += s.quantum
+			}
+			if it := q.items[0]; it.cost <= q.deficit {
+				q.deficit -= it.cost
+				q.items[0] = item[T]{}
+				q.items = q.items[1:]
+				if len(q.items) == 0 {
+					// an idle connection does not keep its credit
+					c.active = c.active[1:]
+					delete(s.queues, q.id)
+				}
+				return it.v, true
+			}
+			// the turn is over, the remaining deficit is kept for the next one
+			q.turn = false
+			c.active = append(c.active[1:], q)
+		}
+	}
+	var zero T
+	return zero, false
+}
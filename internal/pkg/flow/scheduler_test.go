@@ -0,0 +1,90 @@
+package flow
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Order(t *testing.T) {
+	s := NewScheduler[string](DefaultQuantum)
+	for i := 0; i < 3; i++ {
+		s.Push("a", 0, 10, fmt.Sprint("a", i))
+	}
+	done := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		if v, _ := s.Pop(done); v != fmt.Sprint("a", i) {
+			t.Fatalf("expected a%d, got %s", i, v)
+		}
+	}
+	close(done)
+	if _, ok := s.Pop(done); ok {
+		t.Fatal("expected pop to stop when done")
+	}
+}
+
+func TestScheduler_InteractiveLatency(t *testing.T) {
+	const frame = 1 << 10
+	s := NewScheduler[string](DefaultQuantum)
+	// a bulk transfer has queued 8MiB when an interactive connection sends a keystroke
+	for i := 0; i < 8<<10; i++ {
+		s.Push("bulk", 0, frame, "bulk")
+	}
+	s.Pop(nil)
+	s.Push("interactive", 0, 1, "key")
+
+	// the keystroke waits at most for the rest of the bulk turn
+	limit := DefaultQuantum/(frame+frameOverhead) + 1
+	for i := 1; i <= limit; i++ {
+		if v, _ := s.Pop(nil); v == "key" {
+			return
+		}
+	}
+	t.Fatalf("interactive frame not sent within %d frames of the bulk transfer", limit)
+}
+
+func TestScheduler_Fairness(t *testing.T) {
+	s := NewScheduler[string](DefaultQuantum)
+	for i := 0; i < 100; i++ {
+		s.Push("big", 0, 2048, "big")
+		s.Push("small", 0, 512, "small")
+	}
+	// both connections get the same bytes, so four small frames go for every big one
+	bytes := map[string]int{}
+	for i := 0; i < 100; i++ {
+		v, _ := s.Pop(nil)
+		if v == "big" {
+			bytes[v] += 2048
+		} else {
+			bytes[v] += 512
+		}
+	}
+	if diff := bytes["big"] - bytes["small"]; diff > 2*DefaultQuantum || diff < -2*DefaultQuantum {
+		t.Fatalf("expected a fair share of bytes, got %v", bytes)
+	}
+}
+
+func TestScheduler_Priority(t *testing.T) {
+	s := NewScheduler[string](DefaultQuantum)
+	for i := 0; i < 10; i++ {
+		s.Push("bulk", 0, 1024, "bulk")
+	}
+	s.Push("ssh", 1, 1024, "ssh")
+	if v, _ := s.Pop(nil); v != "ssh" {
+		t.Fatalf("expected the higher priority class first, got %s", v)
+	}
+	if v, _ := s.Pop(nil); v != "bulk" {
+		t.Fatalf("expected bulk once the higher class is idle, got %s", v)
+	}
+}
+
+func TestScheduler_PopWaits(t *testing.T) {
+	s := NewScheduler[string](DefaultQuantum)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.Push("a", 0, 1, "a")
+	}()
+	if v, ok := s.Pop(make(chan struct{})); !ok || v != "a" {
+		t.Fatalf("expected a, got %s", v)
+	}
+}
@@ -0,0 +1,93 @@
+package heartbeat
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPeerDead is returned once the peer has stopped answering pings
+var ErrPeerDead = errors.New("peer stopped answering heartbeats")
+
+// Config configures the pings sent on a stream to detect a dead peer
+type Config struct {
+	// Interval is the delay between pings, no pings are sent if it is 0
+	Interval time.Duration
+	// Misses is the number of pings in a row left unanswered before the peer is considered dead
+	Misses int
+}
+
+// Default is used when no heartbeat is configured
+var Default = Config{Interval: 15 * time.Second, Misses: 3}
+
+// Monitor tracks the pings sent to a peer and the pongs it answers them with
+type Monitor struct {
+	config Config
+	// since is the time the monitor started, pongs to earlier pings are left out
+	since int64
+
+	mu         sync.Mutex
+	unanswered int
+	rtt        time.Duration
+}
+
+func NewMonitor(config Config) *Monitor {
+	if config.Misses < 1 {
+		config.Misses = 1
+	}
+	return &Monitor{config: config, since: time.Now().UnixNano()}
+}
+
+// Ping records a ping sent now and returns its timestamp, or ErrPeerDead if too many pings in a row are unanswered
+func (m *Monitor) Ping() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.unanswered >= m.config.Misses {
+		return 0, ErrPeerDead
+	}
+	m.unanswered++
+	return time.Now().UnixNano(), nil
+}
+
+// Pong records the answer to the ping with the given timestamp and returns the round-trip time
+func (m *Monitor) Pong(timestamp int64) time.Duration {
+	rtt := time.Since(time.Unix(0, timestamp))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if timestamp < m.since {
+		// a ping of a previous stream
+		return m.rtt
+	}
+	m.unanswered = 0
+	m.rtt = rtt
+	return rtt
+}
+
+// RTT returns the last round-trip time measured, 0 until a pong is received
+func (m *Monitor) RTT() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rtt
+}
+
+// Run pings the peer at the configured interval until done is closed, it returns ErrPeerDead once the peer stops
+// answering. It returns at once if the interval is 0.
+func (m *Monitor) Run(done <-chan struct{}, ping func(timestamp int64)) error {
+	if m.config.Interval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+			ts, err := m.Ping()
+			if err != nil {
+				return err
+			}
+			ping(ts)
+		}
+	}
+}
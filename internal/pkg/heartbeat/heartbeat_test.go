@@ -0,0 +1,44 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	m := NewMonitor(Config{Interval: time.Second, Misses: 2})
+	ts, err := m.Ping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if rtt := m.Pong(ts); rtt < 5*time.Millisecond || m.RTT() != rtt {
+		t.Fatalf("unexpected round-trip time %s", rtt)
+	}
+	if rtt := m.Pong(m.since - 1); rtt != m.RTT() {
+		t.Fatalf("expected a pong to an earlier ping to be left out, got %s", rtt)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := m.Ping(); err != nil {
+			t.Fatalf("expected ping %d to be sent, got %v", i, err)
+		}
+	}
+	if _, err := m.Ping(); err != ErrPeerDead {
+		t.Fatalf("expected %v, got %v", ErrPeerDead, err)
+	}
+}
+
+func TestMonitor_Run(t *testing.T) {
+	m := NewMonitor(Config{Interval: time.Millisecond, Misses: 3})
+	pings := 0
+	err := m.Run(nil, func(int64) { pings++ })
+	if err != ErrPeerDead || pings != 3 {
+		t.Fatalf("expected the peer dead after 3 pings, got %v after %d", err, pings)
+	}
+
+	done := make(chan struct{})
+	close(done)
+	if err := NewMonitor(Config{Interval: time.Hour}).Run(done, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -23,6 +23,8 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type TunnelServiceClient interface {
 	Tunnel(ctx context.Context, opts ...grpc.CallOption) (TunnelService_TunnelClient, error)
+	// Carries a single connection opened on a Tunnel stream, the first request is its OPEN_ACK
+	Connect(ctx context.Context, opts ...grpc.CallOption) (TunnelService_ConnectClient, error)
 }
 
 type tunnelServiceClient struct {
@@ -64,11 +66,44 @@ func (x *tunnelServiceTunnelClient) Recv() (*TunnelResponse, error) {
 	return m, nil
 }
 
+func (c *tunnelServiceClient) Connect(ctx context.Context, opts ...grpc.CallOption) (TunnelService_ConnectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TunnelService_ServiceDesc.Streams[1], "/tunnel.v1.TunnelService/Connect", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tunnelServiceConnectClient{stream}
+	return x, nil
+}
+
+type TunnelService_ConnectClient interface {
+	Send(*TunnelRequest) error
+	Recv() (*TunnelResponse, error)
+	grpc.ClientStream
+}
+
+type tunnelServiceConnectClient struct {
+	grpc.ClientStream
+}
+
+func (x *tunnelServiceConnectClient) Send(m *TunnelRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *tunnelServiceConnectClient) Recv() (*TunnelResponse, error) {
+	m := new(TunnelResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // TunnelServiceServer is the server API for TunnelService service.
 // All implementations should embed UnimplementedTunnelServiceServer
 // for forward compatibility
 type TunnelServiceServer interface {
 	Tunnel(TunnelService_TunnelServer) error
+	// Carries a single connection opened on a Tunnel stream, the first request is its OPEN_ACK
+	Connect(TunnelService_ConnectServer) error
 }
 
 // UnimplementedTunnelServiceServer should be embedded to have forward compatible implementations.
@@ -78,6 +113,9 @@ type UnimplementedTunnelServiceServer struct {
 func (UnimplementedTunnelServiceServer) Tunnel(TunnelService_TunnelServer) error {
 	return status.Errorf(codes.Unimplemented, "method Tunnel not implemented")
 }
+func (UnimplementedTunnelServiceServer) Connect(TunnelService_ConnectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
 
 // UnsafeTunnelServiceServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to TunnelServiceServer will
@@ -116,6 +154,32 @@ func (x *tunnelServiceTunnelServer) Recv() (*TunnelRequest, error) {
 	return m, nil
 }
 
+func _TunnelService_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TunnelServiceServer).Connect(&tunnelServiceConnectServer{stream})
+}
+
+type TunnelService_ConnectServer interface {
+	Send(*TunnelResponse) error
+	Recv() (*TunnelRequest, error)
+	grpc.ServerStream
+}
+
+type tunnelServiceConnectServer struct {
+	grpc.ServerStream
+}
+
+func (x *tunnelServiceConnectServer) Send(m *TunnelResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *tunnelServiceConnectServer) Recv() (*TunnelRequest, error) {
+	m := new(TunnelRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // TunnelService_ServiceDesc is the grpc.ServiceDesc for TunnelService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -130,6 +194,12 @@ var TunnelService_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Connect",
+			Handler:       _TunnelService_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "tunnel/v1/tunnel.proto",
 }
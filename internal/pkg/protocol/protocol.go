@@ -0,0 +1,104 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/compress"
+	"sort"
+)
+
+// Version is the version of the tunnel protocol spoken by this build, it is raised when the messages change.
+// Peers speak the lower of their versions, which must not be below MinVersion.
+const (
+	Version    uint32 = 1
+	MinVersion uint32 = 1
+)
+
+// MaxDatagramSize is the largest packet of a UDP session, a datagram message carries it whole
+const MaxDatagramSize = 64 << 10
+
+// Capabilities announced in the hello exchange
+const (
+	// FlowControl grants credit with window updates
+	FlowControl = "flow-control"
+	// HalfClose shuts down the write side of connections
+	HalfClose = "half-close"
+	// StreamPerConnection carries connections on their own Connect stream
+	StreamPerConnection = "stream-per-connection"
+	// Pool spreads connections across streams opened in parallel
+	Pool = "pool"
+	// Resume holds the connections of a broken stream for its session to resume
+	Resume = "resume"
+	// Heartbeat pings the peer to measure the round-trip time and detect it is dead
+	Heartbeat = "heartbeat"
+	// Datagram carries UDP sessions, whose packets are never split
+	Datagram = "datagram"
+	// Dial has the server open connections to destinations in its network, for connections the client accepted
+	Dial = "dial"
+	// Destination has the client dial the destination given with a connection instead of the target of its tunnel
+	Destination = "destination"
+)
+
+// ErrIncompatible is returned when a peer cannot be talked to
+var ErrIncompatible = errors.New("incompatible peer")
+
+// Capabilities is a set of capability names
+type Capabilities map[string]bool
+
+func NewCapabilities(names ...string) Capabilities {
+	c := make(Capabilities, len(names))
+	for _, name := range names {
+		c[name] = true
+	}
+	return c
+}
+
+// Supported are the capabilities of this build, along with the compression algorithms it decodes
+var Supported = NewCapabilities(FlowControl, HalfClose, StreamPerConnection, Pool, Resume, Heartbeat, Datagram, Dial, Destination,
+	compress.Gzip.Capability(), compress.Zstd.Capability())
+
+// Required are the capabilities a peer must support
+var Required = NewCapabilities(FlowControl, HalfClose)
+
+func (c Capabilities) Has(name string) bool {
+	return c[name]
+}
+
+// List returns the sorted capability names
+func (c Capabilities) List() []string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Agreement is what two peers agreed on in their hello exchange
+type Agreement struct {
+	Version      uint32
+	Capabilities Capabilities
+}
+
+// Negotiate agrees with a peer on the protocol version and the capabilities both support. It returns an error
+// wrapping ErrIncompatible if the peer speaks too old a version or lacks a required capability.
+func Negotiate(version uint32, capabilities []string) (Agreement, error) {
+	a := Agreement{Version: Version, Capabilities: Capabilities{}}
+	if version < a.Version {
+		a.Version = version
+	}
+	if a.Version < MinVersion {
+		return Agreement{}, fmt.Errorf("%w: protocol version %d, at least %d is required", ErrIncompatible, version, MinVersion)
+	}
+	for _, name := range capabilities {
+		if Supported.Has(name) {
+			a.Capabilities[name] = true
+		}
+	}
+	for _, name := range Required.List() {
+		if !a.Capabilities.Has(name) {
+			return Agreement{}, fmt.Errorf("%w: capability %s is required", ErrIncompatible, name)
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	a, err := Negotiate(Version+1, []string{HalfClose, FlowControl, Heartbeat, "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Version != Version {
+		t.Fatalf("expected version %d, got %d", Version, a.Version)
+	}
+	if got, want := a.Capabilities.List(), []string{FlowControl, HalfClose, Heartbeat}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected capabilities %v, got %v", want, got)
+	}
+
+	if _, err := Negotiate(MinVersion-1, Supported.List()); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("expected an old version to be incompatible, got %v", err)
+	}
+	if _, err := Negotiate(Version, []string{FlowControl}); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("expected a peer without half-close to be incompatible, got %v", err)
+	}
+}
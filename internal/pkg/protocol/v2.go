@@ -0,0 +1,218 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v2"
+	"strconv"
+)
+
+// The tunnel.v2 API carries the same messages as tunnel.v1, each with its own type in a oneof and with numeric
+// connection ids. Both sides translate them to tunnel.v1, so that clients of both versions share the same server.
+
+// errNoMessage is returned for a message of tunnel.v2 without a message set
+var errNoMessage = errors.New("empty message")
+
+// ConnectionID returns the tunnel.v2 id of a connection, which the server issues as decimal numbers
+func ConnectionID(id string) (uint64, error) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("connection id %q is not a number", id)
+	}
+	return n, nil
+}
+
+func formatID(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
+
+func helloToV2(h *tunnelv1.Hello) *tunnelv2.Hello {
+	return &tunnelv2.Hello{Protocol: h.GetProtocol(), Version: h.GetVersion(), Capabilities: h.GetCapabilities()}
+}
+
+func helloFromV2(h *tunnelv2.Hello) *tunnelv1.Hello {
+	return &tunnelv1.Hello{Protocol: h.GetProtocol(), Version: h.GetVersion(), Capabilities: h.GetCapabilities()}
+}
+
+func infoToV2(i *tunnelv1.ConnectionInfo) *tunnelv2.ConnectionInfo {
+	if i == nil {
+		return nil
+	}
+	return &tunnelv2.ConnectionInfo{RemoteAddress: i.RemoteAddress, LocalAddress: i.LocalAddress, AcceptedAt: i.AcceptedAt, ServerName: i.ServerName}
+}
+
+func infoFromV2(i *tunnelv2.ConnectionInfo) *tunnelv1.ConnectionInfo {
+	if i == nil {
+		return nil
+	}
+	return &tunnelv1.ConnectionInfo{RemoteAddress: i.RemoteAddress, LocalAddress: i.LocalAddress, AcceptedAt: i.AcceptedAt, ServerName: i.ServerName}
+}
+
+// RequestToV2 translates a request of the client to tunnel.v2
+func RequestToV2(req *tunnelv1.TunnelRequest) (*tunnelv2.TunnelRequest, error) {
+	switch req.Type {
+	case tunnelv1.RequestType_HELLO:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_Hello{Hello: helloToV2(req.Hello)}}, nil
+	case tunnelv1.RequestType_REGISTER:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_Register{Register: &tunnelv2.Register{
+			Tunnels:             req.Tunnels,
+			Weight:              req.Weight,
+			Window:              req.Window,
+			StreamPerConnection: req.StreamPerConnection,
+			Pool:                req.Pool,
+			SessionId:           req.SessionId,
+		}}}, nil
+	case tunnelv1.RequestType_PING:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_Ping{Ping: &tunnelv2.Ping{Timestamp: req.Timestamp}}}, nil
+	case tunnelv1.RequestType_PONG:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_Pong{Pong: &tunnelv2.Pong{Timestamp: req.Timestamp}}}, nil
+	case tunnelv1.RequestType_DIAL:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_Dial{Dial: &tunnelv2.Dial{DialId: req.DialId, Destination: req.Destination}}}, nil
+	}
+	id, err := ConnectionID(req.ConnectionId)
+	if err != nil {
+		return nil, err
+	}
+	switch req.Type {
+	case tunnelv1.RequestType_OPEN_ACK:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_OpenAck{OpenAck: &tunnelv2.OpenAck{ConnectionId: id}}}, nil
+	case tunnelv1.RequestType_OPEN_FAILED:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_OpenFailed{OpenFailed: &tunnelv2.OpenFailed{ConnectionId: id, Reason: req.Reason, Failure: tunnelv2.Failure(req.Failure)}}}, nil
+	case tunnelv1.RequestType_DATA_RESPONSE:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_Data{Data: &tunnelv2.Data{ConnectionId: id, Seq: req.Seq, Data: req.Data, Compression: tunnelv2.Compression(req.Compression)}}}, nil
+	case tunnelv1.RequestType_CLOSE_WRITE:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_CloseWrite{CloseWrite: &tunnelv2.CloseWrite{ConnectionId: id, Seq: req.Seq}}}, nil
+	case tunnelv1.RequestType_CLOSE:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_Close{Close: &tunnelv2.Close{ConnectionId: id}}}, nil
+	case tunnelv1.RequestType_WINDOW_UPDATE:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_WindowUpdate{WindowUpdate: &tunnelv2.WindowUpdate{ConnectionId: id, Window: req.Window}}}, nil
+	case tunnelv1.RequestType_RESUME:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_Resume{Resume: &tunnelv2.Resume{ConnectionId: id, Seq: req.Seq, Ack: req.Ack}}}, nil
+	case tunnelv1.RequestType_DATAGRAM:
+		return &tunnelv2.TunnelRequest{Message: &tunnelv2.TunnelRequest_Datagram{Datagram: &tunnelv2.Datagram{ConnectionId: id, Data: req.Data}}}, nil
+	}
+	return nil, fmt.Errorf("request %s has no equivalent in tunnel.v2", req.Type)
+}
+
+// RequestFromV2 translates a request of the client from tunnel.v2
+func RequestFromV2(req *tunnelv2.TunnelRequest) (*tunnelv1.TunnelRequest, error) {
+	switch m := req.Message.(type) {
+	case *tunnelv2.TunnelRequest_Hello:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_HELLO, Hello: helloFromV2(m.Hello)}, nil
+	case *tunnelv2.TunnelRequest_Register:
+		return &tunnelv1.TunnelRequest{
+			Type:                tunnelv1.RequestType_REGISTER,
+			Tunnels:             m.Register.GetTunnels(),
+			Weight:              m.Register.GetWeight(),
+			Window:              m.Register.GetWindow(),
+			StreamPerConnection: m.Register.GetStreamPerConnection(),
+			Pool:                m.Register.GetPool(),
+			SessionId:           m.Register.GetSessionId(),
+		}, nil
+	case *tunnelv2.TunnelRequest_OpenAck:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_OPEN_ACK, ConnectionId: formatID(m.OpenAck.GetConnectionId())}, nil
+	case *tunnelv2.TunnelRequest_OpenFailed:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_OPEN_FAILED, ConnectionId: formatID(m.OpenFailed.GetConnectionId()), Reason: m.OpenFailed.GetReason(), Failure: tunnelv1.Failure(m.OpenFailed.GetFailure())}, nil
+	case *tunnelv2.TunnelRequest_Data:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_DATA_RESPONSE, ConnectionId: formatID(m.Data.GetConnectionId()), Seq: m.Data.GetSeq(), Data: m.Data.GetData(), Compression: tunnelv1.Compression(m.Data.GetCompression())}, nil
+	case *tunnelv2.TunnelRequest_CloseWrite:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_CLOSE_WRITE, ConnectionId: formatID(m.CloseWrite.GetConnectionId()), Seq: m.CloseWrite.GetSeq()}, nil
+	case *tunnelv2.TunnelRequest_Close:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_CLOSE, ConnectionId: formatID(m.Close.GetConnectionId())}, nil
+	case *tunnelv2.TunnelRequest_WindowUpdate:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_WINDOW_UPDATE, ConnectionId: formatID(m.WindowUpdate.GetConnectionId()), Window: m.WindowUpdate.GetWindow()}, nil
+	case *tunnelv2.TunnelRequest_Resume:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_RESUME, ConnectionId: formatID(m.Resume.GetConnectionId()), Seq: m.Resume.GetSeq(), Ack: m.Resume.GetAck()}, nil
+	case *tunnelv2.TunnelRequest_Datagram:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_DATAGRAM, ConnectionId: formatID(m.Datagram.GetConnectionId()), Data: m.Datagram.GetData()}, nil
+	case *tunnelv2.TunnelRequest_Dial:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_DIAL, DialId: m.Dial.GetDialId(), Destination: m.Dial.GetDestination()}, nil
+	case *tunnelv2.TunnelRequest_Ping:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_PING, Timestamp: m.Ping.GetTimestamp()}, nil
+	case *tunnelv2.TunnelRequest_Pong:
+		return &tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_PONG, Timestamp: m.Pong.GetTimestamp()}, nil
+	}
+	return nil, errNoMessage
+}
+
+// ResponseToV2 translates a response of the server to tunnel.v2
+func ResponseToV2(resp *tunnelv1.TunnelResponse) (*tunnelv2.TunnelResponse, error) {
+	switch resp.Type {
+	case tunnelv1.ResponseType_HELLO_ACK:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_Hello{Hello: helloToV2(resp.Hello)}}, nil
+	case tunnelv1.ResponseType_REGISTERED:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_Registered{Registered: &tunnelv2.Registered{
+			SessionId:           resp.SessionId,
+			Window:              resp.Window,
+			StreamPerConnection: resp.StreamPerConnection,
+			Resumed:             resp.Resumed,
+			GracePeriod:         resp.GracePeriod,
+		}}}, nil
+	case tunnelv1.ResponseType_HEARTBEAT_PING:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_Ping{Ping: &tunnelv2.Ping{Timestamp: resp.Timestamp}}}, nil
+	case tunnelv1.ResponseType_HEARTBEAT_PONG:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_Pong{Pong: &tunnelv2.Pong{Timestamp: resp.Timestamp}}}, nil
+	case tunnelv1.ResponseType_DIAL_FAILED:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_DialFailed{DialFailed: &tunnelv2.DialFailed{DialId: resp.DialId, Reason: resp.Reason}}}, nil
+	}
+	id, err := ConnectionID(resp.ConnectionId)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.Type {
+	case tunnelv1.ResponseType_OPEN_CONNECTION:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_Open{Open: &tunnelv2.Open{ConnectionId: id, Tunnel: resp.Tunnel, Info: infoToV2(resp.Info), Network: tunnelv2.Network(resp.Network), DialId: resp.DialId, Destination: resp.Destination}}}, nil
+	case tunnelv1.ResponseType_DATA_RECEIVE:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_Data{Data: &tunnelv2.Data{ConnectionId: id, Seq: resp.Seq, Data: resp.Data, Compression: tunnelv2.Compression(resp.Compression)}}}, nil
+	case tunnelv1.ResponseType_CLOSE_WRITE_CONNECTION:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_CloseWrite{CloseWrite: &tunnelv2.CloseWrite{ConnectionId: id, Seq: resp.Seq}}}, nil
+	case tunnelv1.ResponseType_CLOSE_CONNECTION:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_Close{Close: &tunnelv2.Close{ConnectionId: id}}}, nil
+	case tunnelv1.ResponseType_WINDOW_UPDATE_CONNECTION:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_WindowUpdate{WindowUpdate: &tunnelv2.WindowUpdate{ConnectionId: id, Window: resp.Window}}}, nil
+	case tunnelv1.ResponseType_RESUME_CONNECTION:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_Resume{Resume: &tunnelv2.Resume{ConnectionId: id, Seq: resp.Seq, Ack: resp.Ack}}}, nil
+	case tunnelv1.ResponseType_DATAGRAM_RECEIVE:
+		return &tunnelv2.TunnelResponse{Message: &tunnelv2.TunnelResponse_Datagram{Datagram: &tunnelv2.Datagram{ConnectionId: id, Data: resp.Data}}}, nil
+	}
+	return nil, fmt.Errorf("response %s has no equivalent in tunnel.v2", resp.Type)
+}
+
+// ResponseFromV2 translates a response of the server from tunnel.v2
+func ResponseFromV2(resp *tunnelv2.TunnelResponse) (*tunnelv1.TunnelResponse, error) {
+	switch m := resp.Message.(type) {
+	case *tunnelv2.TunnelResponse_Hello:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_HELLO_ACK, Hello: helloFromV2(m.Hello)}, nil
+	case *tunnelv2.TunnelResponse_Registered:
+		return &tunnelv1.TunnelResponse{
+			Type:                tunnelv1.ResponseType_REGISTERED,
+			SessionId:           m.Registered.GetSessionId(),
+			Window:              m.Registered.GetWindow(),
+			StreamPerConnection: m.Registered.GetStreamPerConnection(),
+			Resumed:             m.Registered.GetResumed(),
+			GracePeriod:         m.Registered.GetGracePeriod(),
+		}, nil
+	case *tunnelv2.TunnelResponse_Open:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_OPEN_CONNECTION, ConnectionId: formatID(m.Open.GetConnectionId()), Tunnel: m.Open.GetTunnel(), Info: infoFromV2(m.Open.GetInfo()), Network: tunnelv1.Network(m.Open.GetNetwork()), DialId: m.Open.GetDialId(), Destination: m.Open.GetDestination()}, nil
+	case *tunnelv2.TunnelResponse_Data:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_DATA_RECEIVE, ConnectionId: formatID(m.Data.GetConnectionId()), Seq: m.Data.GetSeq(), Data: m.Data.GetData(), Compression: tunnelv1.Compression(m.Data.GetCompression())}, nil
+	case *tunnelv2.TunnelResponse_CloseWrite:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_CLOSE_WRITE_CONNECTION, ConnectionId: formatID(m.CloseWrite.GetConnectionId()), Seq: m.CloseWrite.GetSeq()}, nil
+	case *tunnelv2.TunnelResponse_Close:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_CLOSE_CONNECTION, ConnectionId: formatID(m.Close.GetConnectionId())}, nil
+	case *tunnelv2.TunnelResponse_WindowUpdate:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_WINDOW_UPDATE_CONNECTION, ConnectionId: formatID(m.WindowUpdate.GetConnectionId()), Window: m.WindowUpdate.GetWindow()}, nil
+	case *tunnelv2.TunnelResponse_Resume:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_RESUME_CONNECTION, ConnectionId: formatID(m.Resume.GetConnectionId()), Seq: m.Resume.GetSeq(), Ack: m.Resume.GetAck()}, nil
+	case *tunnelv2.TunnelResponse_Datagram:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_DATAGRAM_RECEIVE, ConnectionId: formatID(m.Datagram.GetConnectionId()), Data: m.Datagram.GetData()}, nil
+	case *tunnelv2.TunnelResponse_DialFailed:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_DIAL_FAILED, DialId: m.DialFailed.GetDialId(), Reason: m.DialFailed.GetReason()}, nil
+	case *tunnelv2.TunnelResponse_Ping:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_HEARTBEAT_PING, Timestamp: m.Ping.GetTimestamp()}, nil
+	case *tunnelv2.TunnelResponse_Pong:
+		return &tunnelv1.TunnelResponse{Type: tunnelv1.ResponseType_HEARTBEAT_PONG, Timestamp: m.Pong.GetTimestamp()}, nil
+	}
+	return nil, errNoMessage
+}
@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v2"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func TestRequestV2(t *testing.T) {
+	requests := []*tunnelv1.TunnelRequest{
+		{Type: tunnelv1.RequestType_HELLO, Hello: &tunnelv1.Hello{Protocol: Version, Version: "v1.0.0", Capabilities: Supported.List()}},
+		{Type: tunnelv1.RequestType_REGISTER, Tunnels: []string{"a", "b"}, Weight: 2, Window: 1024, StreamPerConnection: true, Pool: "p", SessionId: "s"},
+		{Type: tunnelv1.RequestType_OPEN_ACK, ConnectionId: "1"},
+		{Type: tunnelv1.RequestType_OPEN_FAILED, ConnectionId: "2", Reason: "refused"},
+		{Type: tunnelv1.RequestType_OPEN_FAILED, ConnectionId: "2", Reason: "connection refused", Failure: tunnelv1.Failure_REFUSED},
+		{Type: tunnelv1.RequestType_DATA_RESPONSE, ConnectionId: "3", Seq: 10, Data: []byte("data"), Compression: tunnelv1.Compression_ZSTD},
+		{Type: tunnelv1.RequestType_CLOSE_WRITE, ConnectionId: "3", Seq: 14},
+		{Type: tunnelv1.RequestType_CLOSE, ConnectionId: "3"},
+		{Type: tunnelv1.RequestType_WINDOW_UPDATE, ConnectionId: "4", Window: 512},
+		{Type: tunnelv1.RequestType_RESUME, ConnectionId: "5", Seq: 20, Ack: 18},
+		{Type: tunnelv1.RequestType_DATAGRAM, ConnectionId: "6", Data: []byte("packet")},
+		{Type: tunnelv1.RequestType_DIAL, DialId: 7, Destination: "db.internal:5432"},
+		{Type: tunnelv1.RequestType_PING, Timestamp: 42},
+		{Type: tunnelv1.RequestType_PONG, Timestamp: 43},
+	}
+	for _, req := range requests {
+		v2, err := RequestToV2(req)
+		if err != nil {
+			t.Fatalf("%s: %v", req.Type, err)
+		}
+		got, err := RequestFromV2(v2)
+		if err != nil {
+			t.Fatalf("%s: %v", req.Type, err)
+		}
+		if !proto.Equal(got, req) {
+			t.Fatalf("expected %v, got %v", req, got)
+		}
+	}
+
+	if _, err := RequestToV2(&tunnelv1.TunnelRequest{Type: tunnelv1.RequestType_CLOSE, ConnectionId: "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"}); err == nil {
+		t.Fatal("expected a connection id that is not a number to be refused")
+	}
+	if _, err := RequestFromV2(&tunnelv2.TunnelRequest{}); err == nil {
+		t.Fatal("expected an empty request to be refused")
+	}
+}
+
+func TestResponseV2(t *testing.T) {
+	responses := []*tunnelv1.TunnelResponse{
+		{Type: tunnelv1.ResponseType_HELLO_ACK, Hello: &tunnelv1.Hello{Protocol: Version, Version: "v1.0.0", Capabilities: Required.List()}},
+		{Type: tunnelv1.ResponseType_REGISTERED, SessionId: "s", Window: 1024, StreamPerConnection: true, Resumed: true, GracePeriod: 5000},
+		{Type: tunnelv1.ResponseType_OPEN_CONNECTION, ConnectionId: "1", Tunnel: "a"},
+		{Type: tunnelv1.ResponseType_OPEN_CONNECTION, ConnectionId: "1", Tunnel: "a", Info: &tunnelv1.ConnectionInfo{RemoteAddress: "192.0.2.1:4242", LocalAddress: "198.51.100.1:443", AcceptedAt: 42, ServerName: "example.com"}},
+		{Type: tunnelv1.ResponseType_DATA_RECEIVE, ConnectionId: "1", Seq: 10, Data: []byte("data"), Compression: tunnelv1.Compression_GZIP},
+		{Type: tunnelv1.ResponseType_CLOSE_WRITE_CONNECTION, ConnectionId: "1", Seq: 14},
+		{Type: tunnelv1.ResponseType_CLOSE_CONNECTION, ConnectionId: "1"},
+		{Type: tunnelv1.ResponseType_WINDOW_UPDATE_CONNECTION, ConnectionId: "18446744073709551615", Window: 512},
+		{Type: tunnelv1.ResponseType_RESUME_CONNECTION, ConnectionId: "2", Seq: 20, Ack: 18},
+		{Type: tunnelv1.ResponseType_OPEN_CONNECTION, ConnectionId: "3", Tunnel: "dns", Network: tunnelv1.Network_UDP},
+		{Type: tunnelv1.ResponseType_DATAGRAM_RECEIVE, ConnectionId: "3", Data: []byte("packet")},
+		{Type: tunnelv1.ResponseType_OPEN_CONNECTION, ConnectionId: "4", DialId: 7},
+		{Type: tunnelv1.ResponseType_OPEN_CONNECTION, ConnectionId: "5", Tunnel: "socks", Destination: "10.0.0.1:22"},
+		{Type: tunnelv1.ResponseType_DIAL_FAILED, DialId: 8, Reason: "not allowed"},
+		{Type: tunnelv1.ResponseType_HEARTBEAT_PING, Timestamp: 42},
+		{Type: tunnelv1.ResponseType_HEARTBEAT_PONG, Timestamp: 43},
+	}
+	for _, resp := range responses {
+		v2, err := ResponseToV2(resp)
+		if err != nil {
+			t.Fatalf("%s: %v", resp.Type, err)
+		}
+		got, err := ResponseFromV2(v2)
+		if err != nil {
+			t.Fatalf("%s: %v", resp.Type, err)
+		}
+		if !proto.Equal(got, resp) {
+			t.Fatalf("expected %v, got %v", resp, got)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package proxyproto
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"strconv"
+)
+
+// Version of the HAProxy PROXY protocol, announcing the original addresses of a proxied connection
+type Version int
+
+const (
+	V1 Version = 1
+	V2 Version = 2
+)
+
+// Types of the TLVs of a version 2 header
+const (
+	// TypeAuthority is the host name the client asked for, such as its TLS server name
+	TypeAuthority byte = 0x02
+	// TypeUniqueID identifies the connection, up to 128 bytes
+	TypeUniqueID byte = 0x05
+)
+
+// signature starts a version 2 header
+var signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// TLV is additional information of a version 2 header, version 1 headers drop them
+type TLV struct {
+	Type  byte
+	Value []byte
+}
+
+// ParseVersion returns the version with the given name, v1 or v2
+func ParseVersion(name string) (Version, error) {
+	switch name {
+	case "v1":
+		return V1, nil
+	case "v2":
+		return V2, nil
+	}
+	return 0, fmt.Errorf("unknown PROXY protocol version %q, expected v1 or v2", name)
+}
+
+func (v Version) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+// Header returns the header announcing a TCP connection from src to dst, given as host:port.
+// Addresses that are not IP addresses are announced as unknown.
+func Header(v Version, src, dst string, tlvs ...TLV) ([]byte, error) {
+	s, serr := netip.ParseAddrPort(src)
+	d, derr := netip.ParseAddrPort(dst)
+	known := serr == nil && derr == nil
+	if known {
+		s, d = unmap(s), unmap(d)
+		// mixed families are announced as IPv6
+		if s.Addr().Is4() != d.Addr().Is4() {
+			s = netip.AddrPortFrom(netip.AddrFrom16(s.Addr().As16()), s.Port())
+			d = netip.AddrPortFrom(netip.AddrFrom16(d.Addr().As16()), d.Port())
+		}
+	}
+	switch v {
+	case V1:
+		return v1(known, s, d), nil
+	case V2:
+		return v2(known, s, d, tlvs)
+	}
+	return nil, fmt.Errorf("unknown PROXY protocol version %d", v)
+}
+
+func unmap(a netip.AddrPort) netip.AddrPort {
+	return netip.AddrPortFrom(a.Addr().Unmap(), a.Port())
+}
+
+func v1(known bool, s, d netip.AddrPort) []byte {
+	if !known {
+		return []byte("PROXY UNKNOWN\r\n")
+	}
+	family := "TCP6"
+	if s.Addr().Is4() {
+		family = "TCP4"
+	}
+	return []byte(fmt.Sprintf("PROXY %s %s %s %d %d\r\n", family, s.Addr(), d.Addr(), s.Port(), d.Port()))
+}
+
+func v2(known bool, s, d netip.AddrPort, tlvs []TLV) ([]byte, error) {
+	var addresses []byte
+	// PROXY command, unspecified family unless the addresses are known
+	family := byte(0x00)
+	if known {
+		family = 0x21
+		if s.Addr().Is4() {
+			family = 0x11
+		}
+		addresses = append(addresses, s.Addr().AsSlice()...)
+		addresses = append(addresses, d.Addr().AsSlice()...)
+		addresses = binary.BigEndian.AppendUint16(addresses, s.Port())
+		addresses = binary.BigEndian.AppendUint16(addresses, d.Port())
+	}
+	for _, tlv := range tlvs {
+		if len(tlv.Value) > 0xffff {
+			return nil, fmt.Errorf("TLV %#x too long", tlv.Type)
+		}
+		addresses = append(addresses, tlv.Type)
+		addresses = binary.BigEndian.AppendUint16(addresses, uint16(len(tlv.Value)))
+		addresses = append(addresses, tlv.Value...)
+	}
+	if len(addresses) > 0xffff {
+		return nil, fmt.Errorf("header too long")
+	}
+	header := append(append([]byte(nil), signature...), 0x21, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(addresses)))
+	return append(header, addresses...), nil
+}
@@ -0,0 +1,77 @@
+package proxyproto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestHeader_V1(t *testing.T) {
+	tests := []struct {
+		src, dst string
+		expected string
+	}{
+		{"192.0.2.1:4242", "198.51.100.1:443", "PROXY TCP4 192.0.2.1 198.51.100.1 4242 443\r\n"},
+		{"[2001:db8::1]:4242", "[2001:db8::2]:443", "PROXY TCP6 2001:db8::1 2001:db8::2 4242 443\r\n"},
+		{"[::ffff:192.0.2.1]:4242", "198.51.100.1:443", "PROXY TCP4 192.0.2.1 198.51.100.1 4242 443\r\n"},
+		{"192.0.2.1:4242", "[2001:db8::2]:443", "PROXY TCP6 ::ffff:192.0.2.1 2001:db8::2 4242 443\r\n"},
+		{"pipe", "198.51.100.1:443", "PROXY UNKNOWN\r\n"},
+	}
+	for _, tt := range tests {
+		h, err := Header(V1, tt.src, tt.dst, TLV{Type: TypeUniqueID, Value: []byte("1")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(h) != tt.expected {
+			t.Fatalf("expected %q, got %q", tt.expected, h)
+		}
+	}
+}
+
+func TestHeader_V2(t *testing.T) {
+	h, err := Header(V2, "192.0.2.1:4242", "198.51.100.1:443", TLV{Type: TypeUniqueID, Value: []byte("42")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(h, signature) {
+		t.Fatalf("expected the signature, got %q", h)
+	}
+	h = h[len(signature):]
+	if h[0] != 0x21 || h[1] != 0x11 {
+		t.Fatalf("expected PROXY over TCP4, got %#x %#x", h[0], h[1])
+	}
+	if n := binary.BigEndian.Uint16(h[2:]); int(n) != len(h)-4 {
+		t.Fatalf("expected length %d, got %d", len(h)-4, n)
+	}
+	expected := []byte{192, 0, 2, 1, 198, 51, 100, 1, 0x10, 0x92, 0x01, 0xbb, TypeUniqueID, 0, 2, '4', '2'}
+	if !bytes.Equal(h[4:], expected) {
+		t.Fatalf("expected %v, got %v", expected, h[4:])
+	}
+
+	h, err = Header(V2, "[2001:db8::1]:4242", "[2001:db8::2]:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h[13] != 0x21 || binary.BigEndian.Uint16(h[14:]) != 36 {
+		t.Fatalf("expected 36 bytes of TCP6 addresses, got %v", h[12:16])
+	}
+
+	h, err = Header(V2, "pipe", "pipe", TLV{Type: TypeAuthority, Value: []byte("example.com")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h[13] != 0x00 || binary.BigEndian.Uint16(h[14:]) != 14 {
+		t.Fatalf("expected an unspecified family with only the TLV, got %v", h[12:16])
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	for _, v := range []Version{V1, V2} {
+		if got, err := ParseVersion(v.String()); err != nil || got != v {
+			t.Fatalf("expected %s, got %v, %v", v, got, err)
+		}
+	}
+	if _, err := ParseVersion("v3"); err == nil {
+		t.Fatal("expected an unknown version to be refused")
+	}
+}
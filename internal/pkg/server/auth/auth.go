@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// TokenHeader is the metadata key carrying the client token
+const TokenHeader = "authorization"
+
+// ErrInvalidToken is returned when a client presents no token or an unknown one
+var ErrInvalidToken = errors.New("invalid token")
+
+// Authenticator validates the credentials a client presents on a stream and returns its identity
+type Authenticator interface {
+	Authenticate(ctx context.Context) (string, error)
+}
+
+type identityKey struct{}
+
+// IdentityFromContext returns the identity of the authenticated client
+func IdentityFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(identityKey{}).(string)
+	return id
+}
+
+// tokenFromContext returns the bearer token sent by the client
+func tokenFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, v := range md.Get(TokenHeader) {
+		if token := strings.TrimPrefix(v, "Bearer "); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+type anyOf []Authenticator
+
+// AnyOf accepts clients accepted by any of the authenticators
+func AnyOf(a ...Authenticator) Authenticator {
+	if len(a) == 1 {
+		return a[0]
+	}
+	return anyOf(a)
+}
+
+func (a anyOf) Authenticate(ctx context.Context) (string, error) {
+	err := ErrInvalidToken
+	for _, auth := range a {
+		id, aerr := auth.Authenticate(ctx)
+		if aerr == nil {
+			return id, nil
+		}
+		err = aerr
+	}
+	return "", err
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamInterceptor rejects streams the authenticator does not accept with an Unauthenticated error
+func StreamInterceptor(log *zap.Logger, a Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id, err := a.Authenticate(ss.Context())
+		if err != nil {
+			log.Warn("Rejected client", zap.String("method", info.FullMethod), zap.Error(err))
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		ctx := context.WithValue(ss.Context(), identityKey{}, id)
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
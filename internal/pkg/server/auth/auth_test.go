@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(TokenHeader, "Bearer "+token))
+}
+
+func TestStaticTokens_Authenticate(t *testing.T) {
+	a := NewStaticTokens([]string{"one", "two"})
+	if _, err := a.Authenticate(withToken("two")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Authenticate(withToken("three")); err != ErrInvalidToken {
+		t.Fatalf("expected %v, got %v", ErrInvalidToken, err)
+	}
+	if _, err := a.Authenticate(context.Background()); err != ErrInvalidToken {
+		t.Fatalf("expected %v, got %v", ErrInvalidToken, err)
+	}
+}
+
+func TestHashedTokens_Authenticate(t *testing.T) {
+	hash := sha256.Sum256([]byte("secret"))
+	path := filepath.Join(t.TempDir(), "tokens")
+	content := "# clients\n\n" + hex.EncodeToString(hash[:]) + " team-a\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := LoadHashedTokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := a.Authenticate(withToken("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "token:team-a" {
+		t.Fatalf("expected identity token:team-a, got %s", id)
+	}
+	if _, err := a.Authenticate(withToken("guess")); err != ErrInvalidToken {
+		t.Fatalf("expected %v, got %v", ErrInvalidToken, err)
+	}
+
+	if err := os.WriteFile(path, []byte("not-a-hash\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadHashedTokens(path); err == nil {
+		t.Fatal("expected error for invalid hash")
+	}
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	interceptor := StreamInterceptor(zap.NewNop(), AnyOf(NewStaticTokens([]string{"a"}), NewStaticTokens([]string{"b"})))
+	var identity string
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		identity = IdentityFromContext(stream.Context())
+		return nil
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/tunnel.v1.TunnelService/Tunnel"}
+
+	if err := interceptor(nil, fakeStream{ctx: withToken("b")}, info, handler); err != nil {
+		t.Fatal(err)
+	}
+	if identity != "token:0" {
+		t.Fatalf("expected identity token:0, got %q", identity)
+	}
+	err := interceptor(nil, fakeStream{ctx: withToken("c")}, info, handler)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"strings"
+)
+
+var (
+	// ErrNoCertificate is returned when the client did not present a verified certificate
+	ErrNoCertificate = errors.New("no verified client certificate")
+	// ErrRevoked is returned when the client certificate has been revoked
+	ErrRevoked = errors.New("client certificate revoked")
+	// ErrUnknownIdentity is returned when no name of the client certificate has permissions
+	ErrUnknownIdentity = errors.New("unknown client identity")
+)
+
+// CertificateAuthenticator identifies clients by the certificate verified during the TLS handshake
+type CertificateAuthenticator struct {
+	permissions Permissions
+	revoked     map[string]bool
+}
+
+// NewCertificateAuthenticator creates an authenticator accepting certificates with a name listed in
+// permissions, or any certificate if there are no permissions, unless its fingerprint is revoked. Identities are the
+// names with the certificate prefix.
+func NewCertificateAuthenticator(permissions Permissions, revoked []string) *CertificateAuthenticator {
+	a := &CertificateAuthenticator{permissions: permissions, revoked: make(map[string]bool)}
+	for _, fp := range revoked {
+		a.revoked[normalizeFingerprint(fp)] = true
+	}
+	return a
+}
+
+func (a *CertificateAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", ErrNoCertificate
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", ErrNoCertificate
+	}
+	cert := info.State.VerifiedChains[0][0]
+	if a.revoked[Fingerprint(cert)] {
+		return "", ErrRevoked
+	}
+	names := CertificateNames(cert)
+	if len(a.permissions) == 0 {
+		if len(names) == 0 {
+			return CertPrefix + Fingerprint(cert), nil
+		}
+		return CertPrefix + names[0], nil
+	}
+	for _, name := range names {
+		if a.permissions.has(CertPrefix + name) {
+			return CertPrefix + name, nil
+		}
+	}
+	return "", ErrUnknownIdentity
+}
+
+// Fingerprint returns the hex encoded SHA-256 fingerprint of a certificate
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeFingerprint accepts fingerprints in upper case or separated by colons
+func normalizeFingerprint(fp string) string {
+	return strings.ToLower(strings.ReplaceAll(fp, ":", ""))
+}
+
+// CertificateNames returns the subject common name followed by the subject alternative names of a certificate
+func CertificateNames(cert *x509.Certificate) []string {
+	var names []string
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	return names
+}
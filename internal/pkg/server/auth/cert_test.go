@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCertificate(t *testing.T, cn string, dnsNames ...string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func withCertificate(cert *x509.Certificate) context.Context {
+	info := credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: info})
+}
+
+func TestCertificateAuthenticator_Authenticate(t *testing.T) {
+	cert := newCertificate(t, "client", "team-a.example.com")
+	permissions := NewPermissions(map[string][]string{"Team-A.example.com": {"web"}})
+
+	id, err := NewCertificateAuthenticator(permissions, nil).Authenticate(withCertificate(cert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "cert:team-a.example.com" {
+		t.Fatalf("expected identity cert:team-a.example.com, got %s", id)
+	}
+
+	id, err = NewCertificateAuthenticator(nil, nil).Authenticate(withCertificate(cert))
+	if err != nil || id != "cert:client" {
+		t.Fatalf("expected identity cert:client, got %s (%v)", id, err)
+	}
+
+	other := newCertificate(t, "other")
+	if _, err := NewCertificateAuthenticator(permissions, nil).Authenticate(withCertificate(other)); !errors.Is(err, ErrUnknownIdentity) {
+		t.Fatalf("expected %v, got %v", ErrUnknownIdentity, err)
+	}
+
+	fp := strings.ToUpper(Fingerprint(cert))
+	if _, err := NewCertificateAuthenticator(permissions, []string{fp}).Authenticate(withCertificate(cert)); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected %v, got %v", ErrRevoked, err)
+	}
+
+	if _, err := NewCertificateAuthenticator(permissions, nil).Authenticate(context.Background()); !errors.Is(err, ErrNoCertificate) {
+		t.Fatalf("expected %v, got %v", ErrNoCertificate, err)
+	}
+}
+
+func TestPermissions_Authorize(t *testing.T) {
+	p := NewPermissions(map[string][]string{"a": {"web", "api"}, "token:admin": {AnyTunnel}})
+	if err := p.Authorize("cert:a", []string{"web", "api"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Authorize("cert:a", []string{"web", "db"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	if err := p.Authorize("cert:unknown", []string{"web"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	if err := p.Authorize("token:ADMIN", []string{"db"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Permissions(nil).Authorize("anyone", []string{"db"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPermissions_TokenAndCertificateClients(t *testing.T) {
+	p := NewPermissions(map[string][]string{"team-a.example.com": {"web"}, "token:1": {"api"}})
+	tokens := NewStaticTokens([]string{"first", "second"})
+	p = p.Unrestricted(tokens.Identities())
+
+	// a certificate client is restricted to its tunnels
+	id, err := AnyOf(NewCertificateAuthenticator(p, nil), tokens).Authenticate(withCertificate(newCertificate(t, "team-a.example.com")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Authorize(id, []string{"web"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Authorize(id, []string{"api"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	// token clients are unrestricted unless listed
+	if err := p.Authorize("token:0", []string{"web", "api", "db"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Authorize("token:1", []string{"web"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected the listed token client to be restricted, got %v", err)
+	}
+	// a certificate named after a token client gets none of its permissions
+	for _, cn := range []string{"0", "1", "token:0"} {
+		if _, err := NewCertificateAuthenticator(p, nil).Authenticate(withCertificate(newCertificate(t, cn))); !errors.Is(err, ErrUnknownIdentity) {
+			t.Fatalf("%s: expected %v, got %v", cn, ErrUnknownIdentity, err)
+		}
+	}
+	// nor does a token named after a certificate
+	if err := NewPermissions(map[string][]string{"team-a.example.com": {"web"}}).Authorize("token:team-a.example.com", []string{"web"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	if u := Permissions(nil).Unrestricted(tokens.Identities()); len(u) != 0 {
+		t.Fatalf("expected no permissions to stay unrestricted, got %v", u)
+	}
+}
+
+func TestPermissions_AllowedDial(t *testing.T) {
+	p := NewPermissions(map[string][]string{"a": {"web", DialPrefix + "db.internal:5432", DialPrefix + "10.0.0.0/8:*"}, "b": {"web"}, "admin": {AnyTunnel}})
+	ctx := context.Background()
+	for _, destination := range []string{"db.internal:5432", "10.1.2.3:22"} {
+		if !p.AllowedDial(ctx, "cert:a", destination) {
+			t.Fatalf("expected a to dial %s", destination)
+		}
+	}
+	if p.AllowedDial(ctx, "cert:a", "db.internal:22") || p.AllowedDial(ctx, "cert:b", "db.internal:5432") || p.AllowedDial(ctx, "cert:unknown", "db.internal:5432") {
+		t.Fatal("expected identities to dial only their destinations")
+	}
+	if !p.AllowedDial(ctx, "cert:admin", "db.internal:22") || !Permissions(nil).AllowedDial(ctx, "anyone", "db.internal:22") {
+		t.Fatal("expected unrestricted identities to dial")
+	}
+	// dial entries are no tunnels
+	if err := p.Authorize("cert:a", []string{DialPrefix + "db.internal:5432"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	if err := NewPermissions(map[string][]string{"a": {DialPrefix + "db.internal"}}).Validate(); err == nil {
+		t.Fatal("expected the entry without port to be invalid")
+	}
+}
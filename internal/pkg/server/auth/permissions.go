@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/allow"
+	"strings"
+)
+
+// ErrPermissionDenied is returned when a client registers a tunnel it is not permitted to serve
+var ErrPermissionDenied = errors.New("permission denied")
+
+// AnyTunnel permits an identity to register any tunnel, and to have the server dial any destination it allows
+const AnyTunnel = "*"
+
+// DialPrefix marks the entries of destinations an identity may have the server dial, such as dial:db.internal:5432,
+// written as allowlist entries
+const DialPrefix = "dial:"
+
+// Prefixes of the identities of clients, keeping the names of tokens and certificates apart
+const (
+	TokenPrefix = "token:"
+	CertPrefix  = "cert:"
+)
+
+// Permissions maps client identities to the tunnels they may register and the destinations they may have the server
+// dial. Identities are case-insensitive.
+type Permissions map[string][]string
+
+// NewPermissions returns the permissions of identities written with their prefix, identities without one are the
+// names of certificates
+func NewPermissions(m map[string][]string) Permissions {
+	p := make(Permissions, len(m))
+	for id, tunnels := range m {
+		id = strings.ToLower(id)
+		if !strings.HasPrefix(id, TokenPrefix) && !strings.HasPrefix(id, CertPrefix) {
+			id = CertPrefix + id
+		}
+		p[id] = tunnels
+	}
+	return p
+}
+
+// Validate checks the entries of destinations identities may dial
+func (p Permissions) Validate() error {
+	for id, tunnels := range p {
+		for _, t := range tunnels {
+			if !strings.HasPrefix(t, DialPrefix) {
+				continue
+			}
+			if _, err := allow.Parse([]string{strings.TrimPrefix(t, DialPrefix)}); err != nil {
+				return fmt.Errorf("identity %q: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Unrestricted returns the permissions letting the given identities register any tunnel unless they are listed.
+// It scopes permissions written for certificate identities to them, the identities of token clients are then given.
+// Permissions without entries let any identity register any tunnel already.
+func (p Permissions) Unrestricted(identities []string) Permissions {
+	if len(p) == 0 {
+		return p
+	}
+	u := make(Permissions, len(p)+len(identities))
+	for id, tunnels := range p {
+		u[id] = tunnels
+	}
+	for _, id := range identities {
+		if !p.has(id) {
+			u[strings.ToLower(id)] = []string{AnyTunnel}
+		}
+	}
+	return u
+}
+
+// Lists reports whether identity has an entry
+func (p Permissions) Lists(identity string) bool {
+	return p.has(identity)
+}
+
+func (p Permissions) has(identity string) bool {
+	_, ok := p[strings.ToLower(identity)]
+	return ok
+}
+
+// Allowed reports whether identity may register tunnel, any identity may register any tunnel without permissions
+func (p Permissions) Allowed(identity, tunnel string) bool {
+	if len(p) == 0 {
+		return true
+	}
+	for _, t := range p[strings.ToLower(identity)] {
+		if t == AnyTunnel || (t == tunnel && !strings.HasPrefix(t, DialPrefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedDial reports whether identity may have the server dial destination, any identity may dial without
+// permissions. The allowlist of the server still applies.
+func (p Permissions) AllowedDial(ctx context.Context, identity, destination string) bool {
+	if len(p) == 0 {
+		return true
+	}
+	var entries []string
+	for _, t := range p[strings.ToLower(identity)] {
+		if t == AnyTunnel {
+			return true
+		}
+		if strings.HasPrefix(t, DialPrefix) {
+			entries = append(entries, strings.TrimPrefix(t, DialPrefix))
+		}
+	}
+	destinations, err := allow.Parse(entries)
+	if err != nil {
+		return false
+	}
+	_, err = destinations.Allow(ctx, destination)
+	return err == nil
+}
+
+// Authorize returns ErrPermissionDenied unless identity may register all tunnels
+func (p Permissions) Authorize(identity string, tunnels []string) error {
+	for _, t := range tunnels {
+		if !p.Allowed(identity, t) {
+			return fmt.Errorf("%w: %q may not register tunnel %q", ErrPermissionDenied, identity, t)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StaticTokens accepts clients presenting one of a fixed list of tokens
+type StaticTokens struct {
+	tokens []string
+}
+
+func NewStaticTokens(tokens []string) *StaticTokens {
+	return &StaticTokens{tokens: tokens}
+}
+
+func (a *StaticTokens) Authenticate(ctx context.Context) (string, error) {
+	token := tokenFromContext(ctx)
+	if token == "" {
+		return "", ErrInvalidToken
+	}
+	for i, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return fmt.Sprintf("%s%d", TokenPrefix, i), nil
+		}
+	}
+	return "", ErrInvalidToken
+}
+
+// Identities returns the identities of the clients presenting each token
+func (a *StaticTokens) Identities() []string {
+	ids := make([]string, len(a.tokens))
+	for i := range a.tokens {
+		ids[i] = fmt.Sprintf("%s%d", TokenPrefix, i)
+	}
+	return ids
+}
+
+type hashedToken struct {
+	hash [sha256.Size]byte
+	name string
+}
+
+// HashedTokens accepts clients presenting a token whose SHA-256 hash is known
+type HashedTokens struct {
+	tokens []hashedToken
+}
+
+// LoadHashedTokens reads a tokens file with one hex encoded SHA-256 hash per line, optionally
+// followed by a name identifying the client after the token prefix. Empty lines and lines starting with # are ignored.
+func LoadHashedTokens(path string) (*HashedTokens, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	a := &HashedTokens{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		hash, err := hex.DecodeString(fields[0])
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("%s:%d: expected a hex encoded sha256 hash", path, n)
+		}
+		t := hashedToken{name: fmt.Sprintf("%s%s:%d", TokenPrefix, path, n)}
+		if len(fields) > 1 {
+			t.name = TokenPrefix + fields[1]
+		}
+		copy(t.hash[:], hash)
+		a.tokens = append(a.tokens, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Identities returns the identities of the clients presenting each token
+func (a *HashedTokens) Identities() []string {
+	ids := make([]string, len(a.tokens))
+	for i, t := range a.tokens {
+		ids[i] = t.name
+	}
+	return ids
+}
+
+func (a *HashedTokens) Authenticate(ctx context.Context) (string, error) {
+	token := tokenFromContext(ctx)
+	if token == "" {
+		return "", ErrInvalidToken
+	}
+	hash := sha256.Sum256([]byte(token))
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(hash[:], t.hash[:]) == 1 {
+			return t.name, nil
+		}
+	}
+	return "", ErrInvalidToken
+}
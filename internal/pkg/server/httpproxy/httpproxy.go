@@ -0,0 +1,164 @@
+package httpproxy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrAuth is returned when the peer cannot authenticate
+	ErrAuth = errors.New("proxy authentication failed")
+	// ErrRequest is returned for requests that cannot be proxied
+	ErrRequest = errors.New("unsupported proxy request")
+)
+
+// hopHeaders are meant for the proxy and not passed on to the destination
+var hopHeaders = []string{"Proxy-Authorization", "Proxy-Connection", "Connection", "Keep-Alive", "Te", "Trailer", "Upgrade"}
+
+// Server negotiates HTTP proxy requests, requiring the Basic credentials of one of Users if any
+type Server struct {
+	Users map[string]string
+}
+
+// Request is the request of a proxy peer
+type Request struct {
+	// Destination is the address the peer asks for as host:port
+	Destination string
+	// Connect is set for CONNECT requests, whose data flows through once they are answered
+	Connect bool
+	// Head is the request to send the destination ahead of its body, for requests other than CONNECT
+	Head []byte
+	// Body reads the body of a request other than CONNECT from r, framed as Head announces it, and ends with it so
+	// that the requests the peer sends after it are not passed on to the same destination
+	Body io.Reader
+}
+
+// Handshake reads the request of the peer from r, answering on w those that cannot be served. The body of a request
+// other than CONNECT is left unread in r, for Body to read.
+func (s Server) Handshake(r *bufio.Reader, w io.Writer) (*Request, error) {
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if !s.authenticate(req) {
+		Reply(w, http.StatusProxyAuthRequired)
+		return nil, ErrAuth
+	}
+	if req.Method == http.MethodConnect {
+		if _, _, err := net.SplitHostPort(req.Host); err != nil {
+			Reply(w, http.StatusBadRequest)
+			return nil, fmt.Errorf("%w: CONNECT %s", ErrRequest, req.Host)
+		}
+		return &Request{Destination: req.Host, Connect: true}, nil
+	}
+	if req.URL.Scheme != "http" || req.URL.Host == "" {
+		Reply(w, http.StatusBadRequest)
+		return nil, fmt.Errorf("%w: %s %s", ErrRequest, req.Method, req.RequestURI)
+	}
+	destination := req.URL.Host
+	if _, _, err := net.SplitHostPort(destination); err != nil {
+		destination = net.JoinHostPort(req.URL.Hostname(), "80")
+	}
+	body := io.Reader(req.Body)
+	if len(req.TransferEncoding) > 0 {
+		body = &chunkedBody{body: req.Body}
+	}
+	return &Request{Destination: destination, Head: head(req), Body: body}, nil
+}
+
+// authenticate checks the Proxy-Authorization of the request
+func (s Server) authenticate(req *http.Request) bool {
+	if len(s.Users) == 0 {
+		return true
+	}
+	auth := req.Header.Get("Proxy-Authorization")
+	if !strings.HasPrefix(auth, "Basic ") {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic "))
+	if err != nil {
+		return false
+	}
+	user, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return false
+	}
+	expected, ok := s.Users[user]
+	return ok && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+}
+
+// head writes the request in origin form without the headers meant for the proxy. The destination closes the
+// connection after its response, as the next request of the peer may be for another one.
+func head(req *http.Request) []byte {
+	header := req.Header.Clone()
+	for _, name := range header.Values("Connection") {
+		for _, h := range strings.Split(name, ",") {
+			header.Del(strings.TrimSpace(h))
+		}
+	}
+	for _, h := range hopHeaders {
+		header.Del(h)
+	}
+	if len(req.TransferEncoding) > 0 {
+		header.Set("Transfer-Encoding", strings.Join(req.TransferEncoding, ", "))
+	}
+	header.Set("Connection", "close")
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s %s HTTP/1.1\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), req.URL.Host)
+	header.Write(&b)
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// chunkedBody encodes again in chunks the body the request reader decoded, ending with an empty chunk
+type chunkedBody struct {
+	body io.Reader
+	buf  bytes.Buffer
+	done bool
+}
+
+func (c *chunkedBody) Read(p []byte) (int, error) {
+	for c.buf.Len() == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		chunk := make([]byte, 32*1024)
+		n, err := c.body.Read(chunk)
+		if n > 0 {
+			fmt.Fprintf(&c.buf, "%x\r\n", n)
+			c.buf.Write(chunk[:n])
+			c.buf.WriteString("\r\n")
+		}
+		if err == io.EOF {
+			c.buf.WriteString("0\r\n\r\n")
+			c.done = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return c.buf.Read(p)
+}
+
+// Reply answers a request with status, the connection of a CONNECT request is established if it is 200
+func Reply(w io.Writer, status int) error {
+	if status == http.StatusOK {
+		_, err := io.WriteString(w, "HTTP/1.1 200 Connection established\r\n\r\n")
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "HTTP/1.1 %d %s\r\nConnection: close\r\nContent-Length: 0\r\n", status, http.StatusText(status))
+	if status == http.StatusProxyAuthRequired {
+		b.WriteString("Proxy-Authenticate: Basic realm=\"tunnel\"\r\n")
+	}
+	b.WriteString("\r\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
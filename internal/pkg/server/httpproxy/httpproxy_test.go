@@ -0,0 +1,133 @@
+package httpproxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http/httputil"
+	"strings"
+	"testing"
+)
+
+func TestServer_Handshake(t *testing.T) {
+	tests := []struct {
+		request     string
+		destination string
+		connect     bool
+	}{
+		{"CONNECT db.internal:5432 HTTP/1.1\r\nHost: db.internal:5432\r\n\r\n", "db.internal:5432", true},
+		{"GET http://example.com/a?b=c HTTP/1.1\r\nHost: example.com\r\n\r\n", "example.com:80", false},
+		{"GET http://10.0.0.1:8080/ HTTP/1.1\r\nHost: 10.0.0.1:8080\r\n\r\n", "10.0.0.1:8080", false},
+	}
+	for _, tt := range tests {
+		var w bytes.Buffer
+		req, err := Server{}.Handshake(bufio.NewReader(strings.NewReader(tt.request)), &w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.Destination != tt.destination || req.Connect != tt.connect {
+			t.Fatalf("expected %s (connect %v), got %s (connect %v)", tt.destination, tt.connect, req.Destination, req.Connect)
+		}
+		if w.Len() != 0 {
+			t.Fatalf("expected no answer, got %q", w.String())
+		}
+	}
+}
+
+const next = "GET http://other.example.com/ HTTP/1.1\r\nHost: other.example.com\r\n\r\n"
+
+func TestServer_Head(t *testing.T) {
+	request := "POST http://example.com/submit HTTP/1.1\r\nHost: example.com\r\nProxy-Connection: keep-alive\r\n" +
+		"Connection: X-Hop\r\nX-Hop: 1\r\nX-Kept: 1\r\nContent-Length: 4\r\n\r\nbody" + next
+	r := bufio.NewReader(strings.NewReader(request))
+	req, err := Server{}.Handshake(r, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := string(req.Head)
+	if !strings.HasPrefix(head, "POST /submit HTTP/1.1\r\nHost: example.com\r\n") {
+		t.Fatalf("expected the request in origin form, got %q", head)
+	}
+	for _, h := range []string{"Proxy-Connection", "X-Hop"} {
+		if strings.Contains(head, h) {
+			t.Fatalf("expected %s to be removed, got %q", h, head)
+		}
+	}
+	for _, h := range []string{"X-Kept: 1", "Content-Length: 4", "Connection: close"} {
+		if !strings.Contains(head, h) {
+			t.Fatalf("expected %s to be sent, got %q", h, head)
+		}
+	}
+	// the body is left for the destination, without the next request of the peer
+	if body, _ := io.ReadAll(req.Body); string(body) != "body" {
+		t.Fatalf("expected the body, got %q", body)
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != next {
+		t.Fatalf("expected the next request to be unread, got %q", rest)
+	}
+}
+
+func TestServer_ChunkedBody(t *testing.T) {
+	request := "POST http://example.com/submit HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"4\r\nbody\r\n5\r\n more\r\n0\r\n\r\n" + next
+	r := bufio.NewReader(strings.NewReader(request))
+	req, err := Server{}.Handshake(r, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(req.Head), "Transfer-Encoding: chunked") {
+		t.Fatalf("expected the body to be announced as chunked, got %q", req.Head)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.ReadAll(httputil.NewChunkedReader(bytes.NewReader(body)))
+	if err != nil || string(decoded) != "body more" {
+		t.Fatalf("expected the chunked body, got %q (%v)", body, err)
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != next {
+		t.Fatalf("expected the next request to be unread, got %q", rest)
+	}
+}
+
+func TestServer_Authenticate(t *testing.T) {
+	s := Server{Users: map[string]string{"alice": "secret"}}
+	request := func(credentials string) string {
+		req := "CONNECT db.internal:5432 HTTP/1.1\r\nHost: db.internal:5432\r\n"
+		if credentials != "" {
+			req += "Proxy-Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(credentials)) + "\r\n"
+		}
+		return req + "\r\n"
+	}
+	if _, err := s.Handshake(bufio.NewReader(strings.NewReader(request("alice:secret"))), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	for _, credentials := range []string{"", "alice:wrong", "bob:secret"} {
+		var w bytes.Buffer
+		if _, err := s.Handshake(bufio.NewReader(strings.NewReader(request(credentials))), &w); !errors.Is(err, ErrAuth) {
+			t.Fatalf("expected %q to be refused, got %v", credentials, err)
+		}
+		if !strings.HasPrefix(w.String(), "HTTP/1.1 407 ") || !strings.Contains(w.String(), "Proxy-Authenticate: Basic") {
+			t.Fatalf("expected a 407 answer, got %q", w.String())
+		}
+	}
+}
+
+func TestServer_Unsupported(t *testing.T) {
+	for _, request := range []string{
+		"GET /relative HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		"GET https://example.com/ HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		"CONNECT example.com HTTP/1.1\r\nHost: example.com\r\n\r\n",
+	} {
+		var w bytes.Buffer
+		if _, err := (Server{}).Handshake(bufio.NewReader(strings.NewReader(request)), &w); !errors.Is(err, ErrRequest) {
+			t.Fatalf("expected %q to be refused, got %v", request, err)
+		}
+		if !strings.HasPrefix(w.String(), "HTTP/1.1 400 ") {
+			t.Fatalf("expected a 400 answer, got %q", w.String())
+		}
+	}
+}
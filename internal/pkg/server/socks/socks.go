@@ -0,0 +1,185 @@
+package socks
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+const version = 5
+
+// methods of authentication
+const (
+	methodNone         = 0x00
+	methodPassword     = 0x02
+	methodUnacceptable = 0xff
+)
+
+// version of the username/password subnegotiation
+const passwordVersion = 1
+
+const cmdConnect = 0x01
+
+// address types
+const (
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+)
+
+// Reply codes answering a request
+const (
+	Succeeded           byte = 0x00
+	GeneralFailure      byte = 0x01
+	NotAllowed          byte = 0x02
+	NetworkUnreachable  byte = 0x03
+	HostUnreachable     byte = 0x04
+	ConnectionRefused   byte = 0x05
+	CommandNotSupported byte = 0x07
+	AddressNotSupported byte = 0x08
+)
+
+var (
+	// ErrVersion is returned when the peer does not speak SOCKS5
+	ErrVersion = errors.New("unsupported SOCKS version")
+	// ErrAuth is returned when the peer cannot authenticate
+	ErrAuth = errors.New("SOCKS authentication failed")
+	// ErrCommand is returned for requests other than CONNECT
+	ErrCommand = errors.New("unsupported SOCKS command")
+)
+
+// Server negotiates SOCKS5 CONNECT requests, requiring a username and password of Users if any
+type Server struct {
+	Users map[string]string
+}
+
+// Handshake authenticates the peer and reads its request, returning the destination it asks for as host:port.
+// The request must then be answered with Reply.
+func (s Server) Handshake(rw io.ReadWriter) (string, error) {
+	if err := s.negotiate(rw); err != nil {
+		return "", err
+	}
+	return request(rw)
+}
+
+// negotiate picks the authentication method among those offered by the peer and runs it
+func (s Server) negotiate(rw io.ReadWriter) error {
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(rw, buf); err != nil {
+		return err
+	}
+	if buf[0] != version {
+		return fmt.Errorf("%w: %d", ErrVersion, buf[0])
+	}
+	offered := make([]byte, buf[1])
+	if _, err := io.ReadFull(rw, offered); err != nil {
+		return err
+	}
+	method := byte(methodNone)
+	if len(s.Users) > 0 {
+		method = methodPassword
+	}
+	for _, m := range offered {
+		if m == method {
+			if _, err := rw.Write([]byte{version, method}); err != nil {
+				return err
+			}
+			if method == methodPassword {
+				return s.authenticate(rw)
+			}
+			return nil
+		}
+	}
+	rw.Write([]byte{version, methodUnacceptable})
+	return fmt.Errorf("%w: no acceptable method", ErrAuth)
+}
+
+// authenticate checks the username and password of the peer
+func (s Server) authenticate(rw io.ReadWriter) error {
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(rw, buf); err != nil {
+		return err
+	}
+	if buf[0] != passwordVersion {
+		return fmt.Errorf("%w: password version %d", ErrAuth, buf[0])
+	}
+	user, err := readString(rw, int(buf[1]))
+	if err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(rw, buf[:1]); err != nil {
+		return err
+	}
+	password, err := readString(rw, int(buf[0]))
+	if err != nil {
+		return err
+	}
+	expected, ok := s.Users[user]
+	if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+		rw.Write([]byte{passwordVersion, 0x01})
+		return fmt.Errorf("%w: user %q", ErrAuth, user)
+	}
+	_, err = rw.Write([]byte{passwordVersion, 0x00})
+	return err
+}
+
+// request reads the request of the peer, answering those that cannot be served
+func request(rw io.ReadWriter) (string, error) {
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(rw, buf); err != nil {
+		return "", err
+	}
+	if buf[0] != version {
+		return "", fmt.Errorf("%w: %d", ErrVersion, buf[0])
+	}
+	if buf[1] != cmdConnect {
+		Reply(rw, CommandNotSupported)
+		return "", fmt.Errorf("%w: %d", ErrCommand, buf[1])
+	}
+	var host string
+	switch buf[3] {
+	case atypIPv4, atypIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if buf[3] == atypIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(rw, ip); err != nil {
+			return "", err
+		}
+		host = ip.String()
+	case atypDomain:
+		if _, err := io.ReadFull(rw, buf[:1]); err != nil {
+			return "", err
+		}
+		name, err := readString(rw, int(buf[0]))
+		if err != nil {
+			return "", err
+		}
+		host = name
+	default:
+		Reply(rw, AddressNotSupported)
+		return "", fmt.Errorf("unsupported SOCKS address type %d", buf[3])
+	}
+	if _, err := io.ReadFull(rw, buf[:2]); err != nil {
+		return "", err
+	}
+	port := int(buf[0])<<8 | int(buf[1])
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+func readString(r io.Reader, n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// Reply answers a request with code, the bound address is left unspecified
+func Reply(w io.Writer, code byte) error {
+	_, err := w.Write([]byte{version, code, 0x00, atypIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
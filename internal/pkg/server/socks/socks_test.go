@@ -0,0 +1,104 @@
+package socks
+
+import (
+	"bytes"
+	"errors"
+	"golang.org/x/net/proxy"
+	"io"
+	"net"
+	"testing"
+)
+
+// pipeDialer hands the proxy client one end of a pipe whose other end is served by the test
+type pipeDialer struct {
+	conns chan net.Conn
+}
+
+func (d pipeDialer) Dial(network, addr string) (net.Conn, error) {
+	client, server := net.Pipe()
+	d.conns <- server
+	return client, nil
+}
+
+func handshake(t *testing.T, s Server, auth *proxy.Auth, destination string, code byte) (string, error) {
+	t.Helper()
+	d := pipeDialer{conns: make(chan net.Conn, 1)}
+	dialer, err := proxy.SOCKS5("tcp", "proxy:1080", auth, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialed := make(chan error, 1)
+	go func() {
+		conn, err := dialer.Dial("tcp", destination)
+		if conn != nil {
+			conn.Close()
+		}
+		dialed <- err
+	}()
+	conn := <-d.conns
+	defer conn.Close()
+	got, err := s.Handshake(conn)
+	if err != nil {
+		return "", err
+	}
+	Reply(conn, code)
+	if err := <-dialed; (err == nil) != (code == Succeeded) {
+		t.Fatalf("unexpected dial result for reply %d: %v", code, err)
+	}
+	return got, nil
+}
+
+func TestServer_Handshake(t *testing.T) {
+	tests := []struct {
+		destination string
+		expected    string
+	}{
+		{"10.0.0.1:22", "10.0.0.1:22"},
+		{"[2001:db8::1]:443", "[2001:db8::1]:443"},
+		{"db.internal:5432", "db.internal:5432"},
+	}
+	for _, tt := range tests {
+		got, err := handshake(t, Server{}, nil, tt.destination, Succeeded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.expected {
+			t.Fatalf("expected %s, got %s", tt.expected, got)
+		}
+	}
+	if _, err := handshake(t, Server{}, nil, "10.0.0.1:22", NotAllowed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_Password(t *testing.T) {
+	s := Server{Users: map[string]string{"alice": "secret"}}
+	got, err := handshake(t, s, &proxy.Auth{User: "alice", Password: "secret"}, "10.0.0.1:22", Succeeded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "10.0.0.1:22" {
+		t.Fatalf("unexpected destination %s", got)
+	}
+	if _, err := handshake(t, s, &proxy.Auth{User: "alice", Password: "wrong"}, "10.0.0.1:22", Succeeded); !errors.Is(err, ErrAuth) {
+		t.Fatalf("expected wrong password to fail, got %v", err)
+	}
+	if _, err := handshake(t, s, nil, "10.0.0.1:22", Succeeded); !errors.Is(err, ErrAuth) {
+		t.Fatalf("expected missing credentials to fail, got %v", err)
+	}
+}
+
+func TestServer_Command(t *testing.T) {
+	out := &bytes.Buffer{}
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{bytes.NewReader([]byte{5, 1, 0, 5, 3, 0, 1, 10, 0, 0, 1, 0, 53}), out}
+	if _, err := (Server{}).Handshake(rw); !errors.Is(err, ErrCommand) {
+		t.Fatalf("expected UDP ASSOCIATE to be refused, got %v", err)
+	}
+	// the method selection comes before the reply
+	if reply := out.Bytes(); len(reply) < 4 || !bytes.Equal(reply[:4], []byte{5, 0, 5, CommandNotSupported}) {
+		t.Fatalf("unexpected reply %v", reply)
+	}
+}
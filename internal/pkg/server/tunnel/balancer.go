@@ -0,0 +1,94 @@
+package tunnel
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// Strategy selects how new connections are spread across the clients serving a tunnel
+type Strategy string
+
+const (
+	RoundRobin       Strategy = "round-robin"
+	LeastConnections Strategy = "least-connections"
+	Random           Strategy = "random"
+)
+
+// Strategies lists the supported balancing strategies
+var Strategies = []Strategy{RoundRobin, LeastConnections, Random}
+
+// ParseStrategy returns the strategy with the given name
+func ParseStrategy(name string) (Strategy, error) {
+	for _, s := range Strategies {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown balancing strategy %q", name)
+}
+
+// balancer picks the session serving a new connection among those registered for a tunnel
+type balancer interface {
+	pick(tunnel string, sessions []*session) *session
+}
+
+func newBalancer(s Strategy) balancer {
+	switch s {
+	case LeastConnections:
+		return leastConnections{}
+	case Random:
+		return &random{rnd: rand.New(rand.NewSource(rand.Int63()))}
+	default:
+		return &roundRobin{next: make(map[string]int)}
+	}
+}
+
+type roundRobin struct {
+	mu   sync.Mutex
+	next map[string]int
+}
+
+func (b *roundRobin) pick(tunnel string, sessions []*session) *session {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i := b.next[tunnel] % len(sessions)
+	b.next[tunnel] = i + 1
+	return sessions[i]
+}
+
+type leastConnections struct{}
+
+func (leastConnections) pick(_ string, sessions []*session) *session {
+	var picked *session
+	min := 0
+	for _, ss := range sessions {
+		if n := ss.active(); picked == nil || n < min {
+			picked, min = ss, n
+		}
+	}
+	return picked
+}
+
+// random picks a session with a probability proportional to its weight
+type random struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func (b *random) pick(_ string, sessions []*session) *session {
+	total := 0
+	for _, ss := range sessions {
+		total += ss.weight
+	}
+	b.mu.Lock()
+	n := b.rnd.Intn(total)
+	b.mu.Unlock()
+	for _, ss := range sessions {
+		if n < ss.weight {
+			return ss
+		}
+		n -= ss.weight
+	}
+	return sessions[len(sessions)-1]
+}
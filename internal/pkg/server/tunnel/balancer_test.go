@@ -0,0 +1,59 @@
+package tunnel
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRoundRobin_Pick(t *testing.T) {
+	sessions := []*session{newSession([]string{"a"}, 1, 0), newSession([]string{"a"}, 1, 0), newSession([]string{"a"}, 1, 0)}
+	b := newBalancer(RoundRobin)
+	for i := 0; i < 6; i++ {
+		if got := b.pick("a", sessions); got != sessions[i%3] {
+			t.Fatalf("pick %d: expected session %d", i, i%3)
+		}
+	}
+	if got := b.pick("b", sessions); got != sessions[0] {
+		t.Fatal("expected tunnels to be balanced independently")
+	}
+}
+
+func TestLeastConnections_Pick(t *testing.T) {
+	sessions := []*session{newSession([]string{"a"}, 1, 0), newSession([]string{"a"}, 1, 0)}
+	sessions[0].add(&connection{id: "1"})
+	sessions[0].add(&connection{id: "2"})
+	sessions[1].add(&connection{id: "3"})
+
+	b := newBalancer(LeastConnections)
+	if got := b.pick("a", sessions); got != sessions[1] {
+		t.Fatal("expected session with fewer connections")
+	}
+	sessions[0].remove("1")
+	sessions[0].remove("2")
+	if got := b.pick("a", sessions); got != sessions[0] {
+		t.Fatal("expected session with fewer connections")
+	}
+}
+
+func TestRandom_Pick(t *testing.T) {
+	sessions := []*session{newSession([]string{"a"}, 1, 0), newSession([]string{"a"}, 3, 0)}
+	b := &random{rnd: rand.New(rand.NewSource(1))}
+	picks := map[*session]int{}
+	for i := 0; i < 4000; i++ {
+		picks[b.pick("a", sessions)]++
+	}
+	if n := picks[sessions[1]]; n < 2800 || n > 3200 {
+		t.Fatalf("expected about 3000 picks of the heavier session, got %d", n)
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	for _, s := range Strategies {
+		if got, err := ParseStrategy(string(s)); err != nil || got != s {
+			t.Fatalf("expected %s, got %s (%v)", s, got, err)
+		}
+	}
+	if _, err := ParseStrategy("fastest"); err == nil {
+		t.Fatal("expected error for unknown strategy")
+	}
+}
@@ -0,0 +1,65 @@
+package tunnel
+
+import (
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/server/auth"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Connect carries a connection opened on a dedicated session on its own stream
+func (s *Service) Connect(stream tunnelv1.TunnelService_ConnectServer) error {
+	return s.connect(stream)
+}
+
+func (s *Service) connect(stream serverStream) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if msg.Type != tunnelv1.RequestType_OPEN_ACK {
+		return status.Error(codes.FailedPrecondition, "first message must acknowledge a connection")
+	}
+	owner, conn := s.find(msg.ConnectionId)
+	if conn == nil || conn.attached == nil {
+		return status.Errorf(codes.NotFound, "unknown connection %s", msg.ConnectionId)
+	}
+	if identity := auth.IdentityFromContext(stream.Context()); identity != owner.identity {
+		s.log.Warn("Rejected connection stream", zap.String("connectionId", msg.ConnectionId), zap.String("identity", identity))
+		return status.Error(codes.PermissionDenied, "connection belongs to another client")
+	}
+
+	ss := newSession(owner.tunnels, owner.weight, owner.window)
+	ss.identity = owner.identity
+	ss.single = true
+	ss.add(conn)
+	// the connection takes the first stream, later ones wait until it is closed
+	select {
+	case conn.attached <- ss:
+	case <-conn.done:
+		return status.Errorf(codes.NotFound, "connection %s closed", msg.ConnectionId)
+	case <-stream.Context().Done():
+		return stream.Context().Err()
+	}
+	s.stats.opened.Add(1)
+	conn.ack()
+	s.log.Debug("Connection opened on its own stream", zap.String("connectionId", msg.ConnectionId), zap.String("session", owner.id))
+	err = s.serve(ss, stream)
+	close(ss.done)
+	return err
+}
+
+// find returns the connection with the given id and the session it was opened on
+func (s *Service) find(id string) (*session, *connection) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sessions := range s.sessions {
+		for _, ss := range sessions {
+			if conn, ok := ss.connection(id); ok {
+				return ss, conn
+			}
+		}
+	}
+	return nil, nil
+}
@@ -2,34 +2,63 @@ package tunnel
 
 import (
 	"context"
-	"github.com/google/uuid"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
 	"go.uber.org/zap"
+	"io"
 	"net"
-	"sync"
+	"time"
 )
 
-type Tunnel struct {
-	input, output chan []byte
-}
+// lingerTimeout bounds the time spent writing pending data once a connection is closed
+const lingerTimeout = 5 * time.Second
+
+// handshakeTimeout bounds the time public peers take to send the request of a frontend
+const handshakeTimeout = 10 * time.Second
 
+// Controller hands public connections of a named tunnel to the client serving it
 type Controller struct {
-	log     *zap.Logger
-	s       *Service
-	tunnels map[string]*Tunnel
+	log    *zap.Logger
+	s      *Service
+	tunnel string
+	// serverNameTimeout is the wait for the TLS client hello of public peers, which is not looked for if it is 0
+	serverNameTimeout time.Duration
+	// frontend negotiates the destination of the public peers, which connect to the tunnel target if it is nil
+	frontend frontend
+}
+
+// ControllerOption configures a Controller
+type ControllerOption func(*Controller)
+
+// WithServerName has the controller pass the server name public peers ask for in their TLS client hello to the
+// client, waiting at most timeout for the hello. The peers of the tunnel must speak first.
+func WithServerName(timeout time.Duration) ControllerOption {
+	return func(c *Controller) {
+		c.serverNameTimeout = timeout
+	}
 }
 
-func NewController(log *zap.Logger, service *Service) *Controller {
-	return &Controller{log: log, s: service, tunnels: make(map[string]*Tunnel)}
+// WithSOCKS has the public peers of the tunnel speak SOCKS5, the client dials the destination of their CONNECT
+// request. Peers must authenticate with a username and password of users if any.
+func WithSOCKS(users map[string]string) ControllerOption {
+	return func(c *Controller) {
+		c.frontend = socksFrontend(users)
+	}
 }
 
-func (c *Controller) StartTunnel(input, output chan []byte) (string, error) {
-	id := uuid.New().String()
-	c.tunnels[id] = &Tunnel{input: input, output: output}
-	return id, nil
+// WithHTTPProxy has the public peers of the tunnel speak to an HTTP proxy, the client dials the destination of their
+// CONNECT or absolute-URI requests. Peers must authenticate with the Basic credentials of one of users if any.
+func WithHTTPProxy(users map[string]string) ControllerOption {
+	return func(c *Controller) {
+		c.frontend = httpFrontend(users)
+	}
 }
 
-func (c *Controller) StopTunnel() error {
-	return nil
+func NewController(log *zap.Logger, service *Service, tunnel string, opts ...ControllerOption) *Controller {
+	c := &Controller{log: log, s: service, tunnel: tunnel}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *Controller) Handle(ctx context.Context, conn net.Conn) {
@@ -37,31 +66,118 @@ func (c *Controller) Handle(ctx context.Context, conn net.Conn) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	tConn := connection{id: uuid.New().String(), input: make(chan []byte), output: make(chan []byte)}
-	c.s.TunnelConnection(ctx, tConn)
+	info := &tunnelv1.ConnectionInfo{
+		RemoteAddress: conn.RemoteAddr().String(),
+		LocalAddress:  conn.LocalAddr().String(),
+		AcceptedAt:    time.Now().UnixNano(),
+	}
+	var destination string
+	var reply func(outcome) error
+	if c.frontend != nil {
+		conn.SetDeadline(time.Now().Add(handshakeTimeout))
+		d, fConn, r, err := c.frontend(conn)
+		if err != nil {
+			c.log.Debug("Frontend handshake failed", zap.String("remoteAddress", info.RemoteAddress), zap.Error(err))
+			return
+		}
+		conn.SetDeadline(time.Time{})
+		destination, conn, reply = d, fConn, r
+	} else if c.serverNameTimeout > 0 {
+		name, peeked, err := peekServerName(conn, c.serverNameTimeout)
+		if err != nil {
+			c.log.Debug("No TLS server name", zap.String("remoteAddress", info.RemoteAddress), zap.Error(err))
+		}
+		info.ServerName = name
+		conn = peeked
+	}
+	tConn := &connection{id: c.s.connectionID(), info: info, destination: destination, input: make(chan []byte), failed: make(chan failure, 1), cancel: cancel}
+	if err := c.s.TunnelConnection(ctx, c.tunnel, tConn); err != nil {
+		c.log.Warn("Cannot tunnel connection", zap.Error(err))
+		if reply != nil {
+			reply(outcomeNoClient)
+		}
+		return
+	}
+	if reply != nil && !c.await(ctx, tConn, reply) {
+		return
+	}
+
+	pipe(ctx, cancel, conn, tConn)
+	select {
+	case f := <-tConn.failed:
+		c.log.Info("Closing connection, client cannot reach target", zap.String("connectionId", tConn.id), zap.String("reason", f.reason))
+	default:
+	}
+}
+
+// await answers the request of the public peer once the client reached the destination or failed to, returning
+// whether it did
+func (c *Controller) await(ctx context.Context, tConn *connection, reply func(outcome) error) bool {
+	select {
+	case <-tConn.opened:
+		return reply(outcomeOpened) == nil
+	case <-ctx.Done():
+	}
+	o := outcomeFailed
+	select {
+	case f := <-tConn.failed:
+		c.log.Info("Closing connection, client cannot reach destination", zap.String("connectionId", tConn.id), zap.String("destination", tConn.destination), zap.String("reason", f.reason), zap.Stringer("failure", f.kind))
+		o = outcomeOf(f.kind)
+	default:
+	}
+	reply(o)
+	return false
+}
 
-	wg := &sync.WaitGroup{}
-	wg.Add(2)
+// pipe copies the data of conn to the client and back until ctx is done, either side closing it with cancel
+func pipe(ctx context.Context, cancel context.CancelFunc, conn net.Conn, tConn *connection) {
+	readDone, writeDone := make(chan struct{}), make(chan struct{})
 	go func() {
+		defer close(readDone)
 		for {
 			buf := make([]byte, 1024)
 			n, err := conn.Read(buf)
+			if n > 0 {
+				select {
+				case tConn.input <- buf[:n]:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err == io.EOF {
+				// the public side finished sending, the target may still reply
+				close(tConn.input)
+				return
+			}
 			if err != nil {
+				cancel()
 				return
 			}
-			tConn.input <- buf[:n]
 		}
-		wg.Done()
 	}()
 	go func() {
+		defer close(writeDone)
 		for {
-			data := <-tConn.output
-			_, err := conn.Write(data)
-			if err != nil {
+			data, ok := tConn.output.Pop(ctx.Done())
+			if !ok {
+				if cw, ok := conn.(interface{ CloseWrite() error }); ok && ctx.Err() == nil {
+					cw.CloseWrite()
+				}
+				return
+			}
+			if _, err := conn.Write(data); err != nil {
+				cancel()
 				return
 			}
 		}
-		wg.Done()
 	}()
-	wg.Wait()
+	go func() {
+		<-readDone
+		<-writeDone
+		cancel()
+	}()
+	<-ctx.Done()
+	// let the writer finish before closing so no data is lost
+	conn.SetWriteDeadline(time.Now().Add(lingerTimeout))
+	<-writeDone
 }
@@ -0,0 +1,83 @@
+package tunnel
+
+import (
+	"context"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"go.uber.org/zap"
+	"net"
+	"time"
+)
+
+// rejectHold is the time the session of a peer is kept once it cannot be tunnelled, its packets dropped, so that a
+// flood of them neither opens a session nor logs a rejection for each
+const rejectHold = time.Second
+
+// DatagramController hands the UDP sessions of a named tunnel to the client serving it. Each session is a net.Conn
+// whose reads and writes are single packets of one public source address, such as those of a udp.Server.
+type DatagramController struct {
+	log    *zap.Logger
+	s      *Service
+	tunnel string
+}
+
+func NewDatagramController(log *zap.Logger, service *Service, tunnel string) *DatagramController {
+	return &DatagramController{log: log, s: service, tunnel: tunnel}
+}
+
+func (c *DatagramController) Handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	info := &tunnelv1.ConnectionInfo{
+		RemoteAddress: conn.RemoteAddr().String(),
+		LocalAddress:  conn.LocalAddr().String(),
+		AcceptedAt:    time.Now().UnixNano(),
+	}
+	tConn := &connection{id: c.s.connectionID(), info: info, network: tunnelv1.Network_UDP, input: make(chan []byte), failed: make(chan failure, 1), cancel: cancel}
+	if err := c.s.TunnelConnection(ctx, c.tunnel, tConn); err != nil {
+		c.log.Debug("Dropping packets of UDP session, cannot tunnel it", zap.String("remoteAddress", info.RemoteAddress), zap.Error(err))
+		// the packets are not read, the session drops them once its queue is full
+		select {
+		case <-time.After(rejectHold):
+		case <-ctx.Done():
+		}
+		return
+	}
+
+	go func() {
+		buf := make([]byte, protocol.MaxDatagramSize)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				// the session expired
+				cancel()
+				return
+			}
+			select {
+			// the packet is copied out of the read buffer at its own size
+			case tConn.input <- append([]byte(nil), buf[:n]...):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			data, ok := tConn.output.Pop(ctx.Done())
+			if !ok {
+				return
+			}
+			if _, err := conn.Write(data); err != nil {
+				c.log.Debug("Cannot write packet", zap.String("connectionId", tConn.id), zap.Error(err))
+			}
+		}
+	}()
+	<-ctx.Done()
+	select {
+	case f := <-tConn.failed:
+		c.log.Info("Closing UDP session, client cannot reach target", zap.String("connectionId", tConn.id), zap.String("reason", f.reason))
+	default:
+	}
+}
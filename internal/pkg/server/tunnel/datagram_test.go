@@ -0,0 +1,46 @@
+package tunnel
+
+import (
+	"github.com/costap/tunnelv2/internal/pkg/server/udp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDatagramController_NoClient(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s := NewService(zap.NewNop())
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	us := udp.NewServer(time.Minute)
+	if err := us.Serve(pc, NewDatagramController(zap.New(core), s, "dns")); err != nil {
+		t.Fatal(err)
+	}
+	defer us.Close()
+	public, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer public.Close()
+
+	// the packets of a peer no client serves are dropped in a single session
+	for i := 0; i < 100; i++ {
+		public.Write([]byte("ping"))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Stats().Rejected == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := s.Stats().Rejected; got != 1 {
+		t.Fatalf("expected a single rejected session, got %d", got)
+	}
+	if entries := logs.FilterMessage("Dropping packets of UDP session, cannot tunnel it").All(); len(entries) != 1 || entries[0].Level != zapcore.DebugLevel {
+		t.Fatalf("expected the session to be logged once at debug level, got %v", entries)
+	}
+}
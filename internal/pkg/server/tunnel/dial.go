@@ -0,0 +1,72 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"github.com/costap/tunnelv2/internal/pkg/server/auth"
+	"go.uber.org/zap"
+	"net"
+	"time"
+)
+
+// DialTimeout bounds the time to connect to a destination a client asked for
+const DialTimeout = 10 * time.Second
+
+// errDialDisabled is returned to clients dialing a server without allowed destinations
+var errDialDisabled = errors.New("dialing is disabled on this server")
+
+// dial connects to a destination for a connection the client of a session accepted, and carries it on the session
+// like a public connection
+func (s *Service) dial(ss *session, id uint64, destination string) {
+	log := s.log.With(zap.String("session", ss.id), zap.Uint64("dialId", id), zap.String("destination", destination))
+	conn, err := s.dialDestination(ss, destination)
+	if err != nil {
+		s.stats.dialFailed.Add(1)
+		log.Warn("Cannot dial destination", zap.Error(err))
+		ss.push(0, frame{dial: id, reason: err.Error(), action: action_dial_failed})
+		return
+	}
+	defer conn.Close()
+	s.stats.dialed.Add(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	info := &tunnelv1.ConnectionInfo{
+		RemoteAddress: conn.RemoteAddr().String(),
+		LocalAddress:  conn.LocalAddr().String(),
+		AcceptedAt:    time.Now().UnixNano(),
+	}
+	tConn := &connection{id: s.connectionID(), dial: id, info: info, input: make(chan []byte), failed: make(chan failure, 1), cancel: cancel}
+	if err := s.open(ctx, ss, "", tConn); err != nil {
+		log.Warn("Cannot tunnel dialed connection", zap.Error(err))
+		return
+	}
+	log.Debug("Dialed destination", zap.String("connectionId", tConn.id), zap.String("remoteAddress", info.RemoteAddress))
+	pipe(ctx, cancel, conn, tConn)
+}
+
+// dials reports whether the client of a session may ask the server to dial
+func (s *Service) dials(ss *session) bool {
+	return s.destinations != nil && ss.capabilities.Has(protocol.Dial)
+}
+
+// dialDestination connects to a destination if the server allows it and the identity of the session may dial it
+func (s *Service) dialDestination(ss *session, destination string) (net.Conn, error) {
+	if !s.dials(ss) {
+		return nil, errDialDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DialTimeout)
+	defer cancel()
+	if !s.permissions.AllowedDial(ctx, ss.identity, destination) {
+		return nil, fmt.Errorf("%w: %q may not dial %s", auth.ErrPermissionDenied, ss.identity, destination)
+	}
+	address, err := s.destinations.Allow(ctx, destination)
+	if err != nil {
+		return nil, err
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", address)
+}
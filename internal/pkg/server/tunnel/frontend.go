@@ -0,0 +1,98 @@
+package tunnel
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/server/httpproxy"
+	"github.com/costap/tunnelv2/internal/pkg/server/socks"
+	"io"
+	"net"
+	"net/http"
+)
+
+// outcome is how the client fared with the destination of a public peer
+type outcome int
+
+const (
+	outcomeOpened outcome = iota
+	outcomeNoClient
+	outcomeDenied
+	outcomeRefused
+	outcomeUnreachable
+	outcomeFailed
+)
+
+// outcomeOf is the outcome for the kind of failure the client reported for a destination
+func outcomeOf(kind tunnelv1.Failure) outcome {
+	switch kind {
+	case tunnelv1.Failure_DENIED:
+		return outcomeDenied
+	case tunnelv1.Failure_REFUSED:
+		return outcomeRefused
+	case tunnelv1.Failure_UNREACHABLE:
+		return outcomeUnreachable
+	default:
+		return outcomeFailed
+	}
+}
+
+// frontend reads the request of a public peer and returns the destination it asks for, along with the connection to
+// tunnel, which reads first any data meant for the destination. The peer is answered with reply once the client
+// reached the destination or failed to.
+type frontend func(conn net.Conn) (destination string, tunnelled net.Conn, reply func(outcome) error, err error)
+
+// socksFrontend serves SOCKS5 CONNECT requests
+func socksFrontend(users map[string]string) frontend {
+	s := socks.Server{Users: users}
+	return func(conn net.Conn) (string, net.Conn, func(outcome) error, error) {
+		destination, err := s.Handshake(conn)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return destination, conn, func(o outcome) error {
+			return socks.Reply(conn, socksReplies[o])
+		}, nil
+	}
+}
+
+var socksReplies = map[outcome]byte{
+	outcomeOpened:      socks.Succeeded,
+	outcomeNoClient:    socks.GeneralFailure,
+	outcomeDenied:      socks.NotAllowed,
+	outcomeRefused:     socks.ConnectionRefused,
+	outcomeUnreachable: socks.HostUnreachable,
+	outcomeFailed:      socks.GeneralFailure,
+}
+
+// httpFrontend serves HTTP proxy requests, the destination answers those other than CONNECT itself
+func httpFrontend(users map[string]string) frontend {
+	s := httpproxy.Server{Users: users}
+	return func(conn net.Conn) (string, net.Conn, func(outcome) error, error) {
+		r := bufio.NewReader(conn)
+		req, err := s.Handshake(r, conn)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		// the request is sent again in origin form followed by its body, the peer reconnects for its next request
+		tunnelled := &peekedConn{Conn: conn, r: io.MultiReader(bytes.NewReader(req.Head), req.Body)}
+		if req.Connect {
+			tunnelled.r = r
+		}
+		return req.Destination, tunnelled, func(o outcome) error {
+			if o == outcomeOpened && !req.Connect {
+				return nil
+			}
+			return httpproxy.Reply(conn, httpStatuses[o])
+		}, nil
+	}
+}
+
+var httpStatuses = map[outcome]int{
+	outcomeOpened:      http.StatusOK,
+	outcomeNoClient:    http.StatusServiceUnavailable,
+	outcomeDenied:      http.StatusForbidden,
+	outcomeRefused:     http.StatusBadGateway,
+	outcomeUnreachable: http.StatusGatewayTimeout,
+	outcomeFailed:      http.StatusBadGateway,
+}
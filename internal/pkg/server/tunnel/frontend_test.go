@@ -0,0 +1,71 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHTTPFrontend_Failure(t *testing.T) {
+	tests := []struct {
+		failure  failure
+		expected int
+	}{
+		// the wording of the reason does not matter, only the kind of failure
+		{failure{reason: "cannot connect to target: dial tcp 10.0.0.1:22: the target turned us away", kind: tunnelv1.Failure_REFUSED}, http.StatusBadGateway},
+		{failure{reason: "connection refused", kind: tunnelv1.Failure_UNREACHABLE}, http.StatusGatewayTimeout},
+		{failure{reason: "not for you", kind: tunnelv1.Failure_DENIED}, http.StatusForbidden},
+		// clients that do not report the kind
+		{failure{reason: "connection refused"}, http.StatusBadGateway},
+	}
+	c := NewController(zap.NewNop(), NewService(zap.NewNop()), "proxy")
+	for _, tt := range tests {
+		peer, conn := net.Pipe()
+		go peer.Write([]byte("CONNECT 10.0.0.1:22 HTTP/1.1\r\nHost: 10.0.0.1:22\r\n\r\n"))
+		_, _, reply, err := httpFrontend(nil)(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		tConn := &connection{opened: make(chan struct{}), failed: make(chan failure, 1), cancel: cancel}
+		tConn.fail(tt.failure)
+		go c.await(ctx, tConn, reply)
+		resp, err := http.ReadResponse(bufio.NewReader(peer), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.expected {
+			t.Fatalf("%s %q: expected %d, got %d", tt.failure.kind, tt.failure.reason, tt.expected, resp.StatusCode)
+		}
+		peer.Close()
+		conn.Close()
+	}
+}
+
+func TestHTTPFrontend_Pipelined(t *testing.T) {
+	peer, conn := net.Pipe()
+	defer peer.Close()
+	go peer.Write([]byte("GET http://a.example.com/first HTTP/1.1\r\nHost: a.example.com\r\n\r\n" +
+		"GET http://b.example.com/second HTTP/1.1\r\nHost: b.example.com\r\n\r\n"))
+	destination, tunnelled, _, err := httpFrontend(nil)(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if destination != "a.example.com:80" {
+		t.Fatalf("expected the destination of the first request, got %s", destination)
+	}
+	// only the first request reaches its destination, the peer sends the next one again on a new connection
+	sent, err := io.ReadAll(tunnelled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(sent), "GET /first HTTP/1.1\r\n") || strings.Contains(string(sent), "second") {
+		t.Fatalf("expected only the first request, got %q", sent)
+	}
+}
@@ -0,0 +1,39 @@
+package tunnel
+
+import (
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// hello answers the hello of a client with what they agreed on, refusing clients it cannot talk to
+func (s *Service) hello(stream tunnelStream) (protocol.Agreement, error) {
+	msg, err := stream.Recv()
+	if err != nil {
+		return protocol.Agreement{}, err
+	}
+	if msg.Type != tunnelv1.RequestType_HELLO {
+		s.log.Warn("Rejected client without hello", zap.Stringer("type", msg.Type))
+		return protocol.Agreement{}, status.Error(codes.FailedPrecondition, "first message must be a hello, the client is too old for this server")
+	}
+	agreement, err := protocol.Negotiate(msg.Hello.GetProtocol(), msg.Hello.GetCapabilities())
+	if err != nil {
+		s.log.Warn("Rejected incompatible client", zap.String("clientVersion", msg.Hello.GetVersion()), zap.Error(err))
+		return protocol.Agreement{}, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if s.destinations == nil {
+		// nothing may be dialed, clients are told to keep their local forwards off
+		delete(agreement.Capabilities, protocol.Dial)
+	}
+	ack := &tunnelv1.TunnelResponse{
+		Type:  tunnelv1.ResponseType_HELLO_ACK,
+		Hello: &tunnelv1.Hello{Protocol: protocol.Version, Version: s.version, Capabilities: agreement.Capabilities.List()},
+	}
+	if err := stream.Send(ack); err != nil {
+		return protocol.Agreement{}, err
+	}
+	s.log.Info("Client hello", zap.String("clientVersion", msg.Hello.GetVersion()), zap.Uint32("protocol", agreement.Version), zap.Strings("capabilities", agreement.Capabilities.List()))
+	return agreement, nil
+}
@@ -2,92 +2,999 @@ package tunnel
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/allow"
+	"github.com/costap/tunnelv2/internal/pkg/compress"
+	"github.com/costap/tunnelv2/internal/pkg/flow"
+	"github.com/costap/tunnelv2/internal/pkg/heartbeat"
 	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"github.com/costap/tunnelv2/internal/pkg/server/auth"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"hash/fnv"
+	"io"
+	"math"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrNoSession is returned when no client is registered for a tunnel
+var ErrNoSession = errors.New("no client registered for tunnel")
+
+// ErrNoDatagrams is returned when the client picked for a UDP session does not support datagrams
+var ErrNoDatagrams = errors.New("client does not support datagrams")
+
+// ErrNoDestinations is returned when the client picked for a connection to a destination cannot dial it
+var ErrNoDestinations = errors.New("client does not dial destinations")
+
+// errSessionClosed is returned when the session closes before a connection is opened
+var errSessionClosed = errors.New("session closed")
+
 type action int
 
 const (
 	action_open action = iota
 	action_close
+	action_close_write
 	action_data
+	action_window
+	action_resume
+	action_ping
+	action_pong
+	action_datagram
+	action_dial_failed
+)
+
+// heartbeatPriority sends heartbeats ahead of the connections so that the round-trip time leaves out their queueing
+const heartbeatPriority = math.MaxInt32
+
+// Scheduler queues of the frames of no connection, apart so that each keeps its own priority class
+const (
+	heartbeatQueue = "#heartbeat"
+	dialQueue      = "#dial"
 )
 
 type frame struct {
 	id     string
+	tunnel string
 	data   []byte
-	action action
+	window int
+	// seq is the offset of the data or of its end, ack the bytes consumed, for a resumed connection
+	seq int64
+	ack int64
+	// timestamp is the time a ping was sent at
+	timestamp int64
+	// compression is the algorithm the data is compressed with
+	compression compress.Algorithm
+	// info describes the public side of an opened connection, network its transport
+	info    *tunnelv1.ConnectionInfo
+	network tunnelv1.Network
+	// dial is the dial of the client an opened connection answers or that failed for reason
+	dial   uint64
+	reason string
+	// destination is the address the client dials for an opened connection, if any
+	destination string
+	action      action
 }
 type connection struct {
-	id            string
-	input, output chan []byte
+	id string
+	// info describes the public side of the connection
+	info *tunnelv1.ConnectionInfo
+	// network is the transport of the public side, the input and output of a UDP session are single packets
+	network tunnelv1.Network
+	// dial is the dial of the client the connection answers, 0 for a public connection
+	dial uint64
+	// destination is the address the client dials instead of the target of the tunnel, if any
+	destination string
+	// input is closed once the public side stops sending
+	input chan []byte
+	// output queues the data of the target, it is closed once the target stops sending
+	output *flow.Buffer
+	// window is the credit granted by the client to send it data
+	window *flow.Window
+	// replay keeps the data sent until the client consumed it, nil unless the session can be resumed.
+	// sent is the offset of the end of the data sent, mu orders the frames queued with them.
+	replay *flow.Replay
+	sent   int64
+	mu     sync.Mutex
+	// encoder compresses the data sent to the client
+	encoder compress.Encoder
+	// priority is the scheduling class of the tunnel
+	priority int
+	// attached receives the session of the Connect stream carrying the connection, if the client opens one
+	attached chan *session
+	// failed receives why the client could not reach the target
+	failed chan failure
+	// opened is closed once the client reached the target
+	opened   chan struct{}
+	openOnce sync.Once
+	done     <-chan struct{}
+	cancel   context.CancelFunc
+}
+
+// ack reports the target was reached, it is safe to call more than once
+func (c *connection) ack() {
+	c.openOnce.Do(func() {
+		close(c.opened)
+	})
+}
+
+// failure is why the client could not reach the target of a connection
+type failure struct {
+	reason string
+	kind   tunnelv1.Failure
+}
+
+// fail reports the target could not be reached and closes the connection
+func (c *connection) fail(f failure) {
+	select {
+	case c.failed <- f:
+	default:
+	}
+	c.cancel()
+}
+
+// session is a single client stream serving one or more named tunnels
+type session struct {
+	id       string
+	identity string
+	tunnels  []string
+	weight   int
+	// window is the initial credit granted by the client to each connection
+	window int
+	// dedicated sessions have the client open a Connect stream per connection
+	dedicated bool
+	// single sessions are the Connect stream of one connection, ending with it
+	single bool
+	// pool is shared by the streams a client opens in parallel, empty for a client with a single stream
+	pool string
+	// capabilities are those agreed on with the client
+	capabilities protocol.Capabilities
+	// load counts the open connections of the streams of the pool
+	load   *atomic.Int64
+	output *flow.Scheduler[frame]
+	// done is closed once the session ends, with its stream or when it is not resumed in time
+	done chan struct{}
+	// streams waits for the goroutines serving a stream, expiry ends a session waiting to be resumed
+	streams sync.WaitGroup
+	expiry  *time.Timer
+	// monitor tracks the heartbeats of the current stream
+	monitor *heartbeat.Monitor
+
+	mu          sync.Mutex
+	connections map[string]*connection
+}
+
+func newSession(tunnels []string, weight, window int) *session {
+	if weight < 1 {
+		weight = 1
+	}
+	if window < 1 {
+		window = flow.DefaultWindow
+	}
+	return &session{
+		id:          uuid.New().String(),
+		tunnels:     tunnels,
+		weight:      weight,
+		window:      window,
+		output:      flow.NewScheduler[frame](flow.DefaultQuantum),
+		load:        &atomic.Int64{},
+		done:        make(chan struct{}),
+		connections: make(map[string]*connection),
+	}
+}
+
+// push queues a frame for the client, it returns false if the session is closed
+func (ss *session) push(priority int, f frame) bool {
+	select {
+	case <-ss.done:
+		return false
+	default:
+	}
+	ss.output.Push(f.queue(), priority, len(f.data), f)
+	return true
+}
+
+// queue returns the scheduler queue of a frame, that of its connection if it has one
+func (f frame) queue() string {
+	switch {
+	case f.id != "":
+		return f.id
+	case f.action == action_ping || f.action == action_pong:
+		return heartbeatQueue
+	default:
+		return dialQueue
+	}
+}
+
+// pushData queues data for the client, keeping it to send again if the session is resumed
+func (ss *session) pushData(conn *connection, data []byte) bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	seq := conn.sent
+	conn.sent += int64(len(data))
+	if conn.replay != nil {
+		conn.replay.Append(data)
+	}
+	data, alg := conn.encoder.Encode(data)
+	return ss.push(conn.priority, frame{id: conn.id, data: data, seq: seq, compression: alg, action: action_data})
+}
+
+// pushDatagram queues a packet for the client, packets are not kept since UDP does not send them again
+func (ss *session) pushDatagram(conn *connection, data []byte) bool {
+	return ss.push(conn.priority, frame{id: conn.id, data: data, action: action_datagram})
+}
+
+// pushCloseWrite queues the end of the data for the client, keeping it to send again if the session is resumed
+func (ss *session) pushCloseWrite(conn *connection) bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.replay != nil {
+		conn.replay.Close()
+	}
+	return ss.push(conn.priority, frame{id: conn.id, seq: conn.sent, action: action_close_write})
+}
+
+// pushWindow grants the client the credit of the output consumed
+func (ss *session) pushWindow(conn *connection) bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return ss.push(conn.priority, frame{id: conn.id, window: conn.output.Release(), action: action_window})
+}
+
+// pushResume tells the client what the connection received so it sends again what was lost with its stream
+func (ss *session) pushResume(conn *connection) bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	received, consumed := conn.output.Position()
+	return ss.push(conn.priority, frame{id: conn.id, seq: received, ack: consumed, action: action_resume})
+}
+
+// replay restores the credit the client granted while its stream was broken and sends again the data it missed
+func (ss *session) replay(conn *connection, seq, ack int64) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.replay == nil {
+		return
+	}
+	conn.window.Add(conn.replay.AckTo(ack))
+	// the client received at least what it consumed, so the data kept starts at or before seq
+	chunks, end, closed := conn.replay.From(seq)
+	for _, data := range chunks {
+		wire, alg := conn.encoder.Encode(data)
+		ss.push(conn.priority, frame{id: conn.id, data: wire, seq: seq, compression: alg, action: action_data})
+		seq += int64(len(data))
+	}
+	if closed {
+		ss.push(conn.priority, frame{id: conn.id, seq: end, action: action_close_write})
+	}
+}
+
+func (ss *session) add(conn *connection) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, ok := ss.connections[conn.id]; !ok {
+		ss.load.Add(1)
+	}
+	ss.connections[conn.id] = conn
+}
+
+func (ss *session) remove(id string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, ok := ss.connections[id]; ok {
+		ss.load.Add(-1)
+	}
+	delete(ss.connections, id)
+}
+
+// active returns the number of open connections of the client, over all the streams of its pool
+func (ss *session) active() int {
+	return int(ss.load.Load())
+}
+
+// poolKey identifies the client a stream belongs to
+func (ss *session) poolKey() string {
+	if ss.pool == "" {
+		return ss.id
+	}
+	return ss.identity + "/" + ss.pool
+}
+
+// rtt records the answer to a heartbeat and returns the round-trip time
+func (ss *session) rtt(timestamp int64) time.Duration {
+	ss.mu.Lock()
+	monitor := ss.monitor
+	ss.mu.Unlock()
+	return monitor.Pong(timestamp)
 }
+
+func (ss *session) connection(id string) (*connection, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	conn, ok := ss.connections[id]
+	return conn, ok
+}
+
 type Service struct {
-	log         *zap.Logger
-	connections map[string]connection
-	output      chan frame
+	log *zap.Logger
+	// version of the server binary, sent to clients
+	version     string
+	balancer    balancer
+	permissions auth.Permissions
+	window      int
+	priorities  map[string]int
+	// streamPerConnection carries connections on their own stream for clients supporting it
+	streamPerConnection bool
+	// grace is how long the connections of a broken stream are held for the client to resume its session
+	grace time.Duration
+	// compression is the algorithm compressing the data sent on each tunnel, for data of at least threshold bytes
+	compression map[string]compress.Algorithm
+	threshold   int
+	heartbeat   heartbeat.Config
+	// destinations are those clients may have the server dial, none if nil
+	destinations *allow.List
+	stats        stats
+	// ids numbers the connections
+	ids atomic.Uint64
+
+	mu       sync.RWMutex
+	sessions map[string][]*session
+	// detached are the sessions waiting to be resumed, by id
+	detached map[string]*session
+}
+
+// ServiceOption configures a Service
+type ServiceOption func(*Service)
+
+// WithBalancer sets the strategy spreading connections across clients serving the same tunnel
+func WithBalancer(strategy Strategy) ServiceOption {
+	return func(s *Service) {
+		s.balancer = newBalancer(strategy)
+	}
+}
+
+// WithPermissions restricts the tunnels each client identity may register
+func WithPermissions(permissions auth.Permissions) ServiceOption {
+	return func(s *Service) {
+		s.permissions = permissions
+	}
+}
+
+// WithWindow sets the bytes a client may send on each connection before the server has consumed them
+func WithWindow(size int) ServiceOption {
+	return func(s *Service) {
+		s.window = size
+	}
+}
+
+// WithPriorities sets the scheduling class of tunnels, the frames of higher classes are sent to a client first.
+// Tunnels default to class 0.
+func WithPriorities(priorities map[string]int) ServiceOption {
+	return func(s *Service) {
+		s.priorities = priorities
+	}
+}
+
+// WithStreamPerConnection has clients that support it open a dedicated stream for each connection,
+// so that HTTP/2 flow control applies per connection instead of to the shared stream
+func WithStreamPerConnection(enabled bool) ServiceOption {
+	return func(s *Service) {
+		s.streamPerConnection = enabled
+	}
+}
+
+// WithGracePeriod holds the connections of a client whose stream broke for the given time, so that the client can
+// resume its session and carry on with them. Connections are closed with the stream if it is 0.
+func WithGracePeriod(d time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.grace = d
+	}
+}
+
+// WithCompression sets the algorithm compressing the data sent to clients on each tunnel, for clients supporting it.
+// Tunnels default to no compression.
+func WithCompression(algorithms map[string]compress.Algorithm) ServiceOption {
+	return func(s *Service) {
+		s.compression = algorithms
+	}
+}
+
+// WithCompressionThreshold sets the size below which data is sent uncompressed
+func WithCompressionThreshold(size int) ServiceOption {
+	return func(s *Service) {
+		s.threshold = size
+	}
+}
+
+// WithVersion sets the version of the server binary sent to clients
+func WithVersion(version string) ServiceOption {
+	return func(s *Service) {
+		s.version = version
+	}
+}
+
+// WithDial lets clients have the server open connections to the destinations of the list, for connections
+// the clients accepted
+func WithDial(destinations *allow.List) ServiceOption {
+	return func(s *Service) {
+		s.destinations = destinations
+	}
+}
+
+// WithHeartbeat sets the interval of the pings sent to clients and the number of unanswered pings after which a
+// client is considered dead and its stream torn down
+func WithHeartbeat(config heartbeat.Config) ServiceOption {
+	return func(s *Service) {
+		s.heartbeat = config
+	}
+}
+
+func NewService(log *zap.Logger, opts ...ServiceOption) *Service {
+	s := &Service{
+		log:       log,
+		balancer:  newBalancer(RoundRobin),
+		window:    flow.DefaultWindow,
+		threshold: compress.DefaultThreshold,
+		heartbeat: heartbeat.Default,
+		sessions:  make(map[string][]*session),
+		detached:  make(map[string]*session),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// connectionID returns the id of a new connection, a number so that tunnel.v2 carries it as a varint
+func (s *Service) connectionID() string {
+	return strconv.FormatUint(s.ids.Add(1), 10)
+}
+
+func (s *Service) register(ss *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the streams of a pool share their count of connections
+	if other := s.member(ss.poolKey()); other != nil {
+		ss.load = other.load
+	}
+	for _, t := range ss.tunnels {
+		s.sessions[t] = append(s.sessions[t], ss)
+	}
 }
 
-func NewService(log *zap.Logger) *Service {
-	return &Service{
-		log:         log,
-		connections: make(map[string]connection),
-		output:      make(chan frame),
+// member returns a registered stream of a pool, s.mu must be held
+func (s *Service) member(key string) *session {
+	for _, sessions := range s.sessions {
+		for _, ss := range sessions {
+			if ss.poolKey() == key {
+				return ss
+			}
+		}
 	}
+	return nil
 }
 
-func (s *Service) TunnelConnection(ctx context.Context, conn connection) {
-	s.connections[conn.id] = conn
-	s.output <- frame{id: conn.id, action: action_open}
+func (s *Service) unregister(ss *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range ss.tunnels {
+		sessions := s.sessions[t]
+		for i := range sessions {
+			if sessions[i] == ss {
+				sessions = append(sessions[:i], sessions[i+1:]...)
+				break
+			}
+		}
+		if len(sessions) == 0 {
+			delete(s.sessions, t)
+		} else {
+			s.sessions[t] = sessions
+		}
+	}
+}
+
+// detach holds the connections of a session whose stream broke until the client resumes it or the grace period ends
+func (s *Service) detach(ss *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detached[ss.id] = ss
+	ss.expiry = time.AfterFunc(s.grace, func() {
+		s.mu.Lock()
+		if s.detached[ss.id] != ss {
+			s.mu.Unlock()
+			return
+		}
+		delete(s.detached, ss.id)
+		s.mu.Unlock()
+		s.log.Info("Session expired", zap.String("session", ss.id))
+		close(ss.done)
+	})
+}
+
+// resume returns the detached session with the given id if it belongs to identity, nil if it expired
+func (s *Service) resume(id, identity string) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss, ok := s.detached[id]
+	if !ok || ss.identity != identity || !ss.expiry.Stop() {
+		return nil
+	}
+	delete(s.detached, id)
+	return ss
+}
+
+// serves reports whether a client has registered the tunnel
+func (s *Service) serves(tunnel string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.sessions[tunnel]) > 0
+}
+
+// pick returns the session serving a new connection of a tunnel, nil if no client has registered it.
+// The balancer spreads connections across clients, the streams of a client's pool share them by connection id.
+func (s *Service) pick(tunnel, id string) *session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sessions := s.sessions[tunnel]
+	if len(sessions) == 0 {
+		return nil
+	}
+	var leaders []*session
+	pools := make(map[string][]*session)
+	for _, ss := range sessions {
+		key := ss.poolKey()
+		if _, ok := pools[key]; !ok {
+			leaders = append(leaders, ss)
+		}
+		pools[key] = append(pools[key], ss)
+	}
+	members := pools[s.balancer.pick(tunnel, leaders).poolKey()]
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return members[h.Sum32()%uint32(len(members))]
+}
+
+// TunnelConnection hands a public connection to one of the client sessions serving the tunnel
+func (s *Service) TunnelConnection(ctx context.Context, tunnel string, conn *connection) error {
+	ss := s.pick(tunnel, conn.id)
+	if ss == nil {
+		s.stats.rejected.Add(1)
+		return fmt.Errorf("%w: %s", ErrNoSession, tunnel)
+	}
+	if conn.network == tunnelv1.Network_UDP && !ss.capabilities.Has(protocol.Datagram) {
+		s.stats.rejected.Add(1)
+		return fmt.Errorf("%w: %s", ErrNoDatagrams, tunnel)
+	}
+	if conn.destination != "" && !ss.capabilities.Has(protocol.Destination) {
+		s.stats.rejected.Add(1)
+		return fmt.Errorf("%w: %s", ErrNoDestinations, tunnel)
+	}
+	s.stats.accepted.Add(1)
+	s.log.Debug("Tunneling connection", zap.String("tunnel", tunnel), zap.String("connectionId", conn.id), zap.String("session", ss.id), zap.String("remoteAddress", conn.info.GetRemoteAddress()))
+	return s.open(ctx, ss, tunnel, conn)
+}
+
+// open has the client of a session open a connection, and sends it the data of the public side until it is closed
+func (s *Service) open(ctx context.Context, ss *session, tunnel string, conn *connection) error {
+	conn.done = ctx.Done()
+	conn.opened = make(chan struct{})
+	conn.output = flow.NewBuffer(s.window)
+	conn.window = flow.NewWindow(ss.window)
+	// the data is only kept while the connection would be held for the stream to resume, UDP sessions never are
+	if s.resumable(ss) && conn.network == tunnelv1.Network_TCP {
+		conn.replay = flow.NewReplay()
+	}
+	conn.priority = s.priorities[tunnel]
+	if alg := s.compression[tunnel]; ss.capabilities.Has(alg.Capability()) && conn.network == tunnelv1.Network_TCP {
+		conn.encoder = compress.Encoder{Algorithm: alg, Threshold: s.threshold, Counter: &s.stats.compressedSent}
+	}
+	// dialed connections share the stream of their dial
+	if ss.dedicated && conn.dial == 0 {
+		conn.attached = make(chan *session)
+	}
+	ss.add(conn)
+	if !ss.push(conn.priority, frame{id: conn.id, tunnel: tunnel, info: conn.info, network: conn.network, dial: conn.dial, destination: conn.destination, action: action_open}) {
+		ss.remove(conn.id)
+		return errSessionClosed
+	}
 	go func() {
-		for {
+		defer ss.remove(conn.id)
+		out := ss
+		if ss.dedicated {
 			select {
-			case data := <-conn.input:
-				s.output <- frame{id: conn.id, data: data, action: action_data}
+			case out = <-conn.attached:
 			case <-ctx.Done():
-				s.output <- frame{id: conn.id, action: action_close}
+				ss.push(conn.priority, frame{id: conn.id, action: action_close})
+				return
+			case <-ss.done:
+				conn.cancel()
 				return
 			}
 		}
+		if !s.forward(ctx, out, conn) {
+			conn.cancel()
+			return
+		}
+		out.push(conn.priority, frame{id: conn.id, action: action_close})
 	}()
+	return nil
 }
 
-func (s *Service) Tunnel(stream tunnelv1.TunnelService_TunnelServer) error {
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		for {
-			msg, err := stream.Recv()
-			if err != nil {
-				s.log.Error("Failed to read from stream", zap.Error(err))
-				break
+// forward sends the data of the public side to the client within the credit it granted, along with the credit
+// granted to the client as the output is consumed. It returns false if the session closed before the connection.
+func (s *Service) forward(ctx context.Context, ss *session, conn *connection) bool {
+	input := conn.input
+	var pending []byte
+	for {
+		if n := conn.window.Take(len(pending)); n > 0 {
+			if !ss.pushData(conn, pending[:n]) {
+				return false
+			}
+			pending = pending[n:]
+			continue
+		}
+		// read the input only once the pending data is sent
+		in, credit := input, (<-chan struct{})(nil)
+		if len(pending) > 0 {
+			in, credit = nil, conn.window.Ready()
+		}
+		select {
+		case data, ok := <-in:
+			if !ok {
+				// keep the connection open for the target to finish sending
+				input = nil
+				if !ss.pushCloseWrite(conn) {
+					return false
+				}
+				continue
+			}
+			if conn.network == tunnelv1.Network_UDP {
+				if !s.forwardDatagram(ss, conn, data) {
+					return false
+				}
+				continue
+			}
+			pending = data
+		case <-credit:
+		case <-conn.output.Updates():
+			if !ss.pushWindow(conn) {
+				return false
 			}
-			s.connections[msg.ConnectionId].output <- msg.Data
+		case <-ctx.Done():
+			return true
+		case <-ss.done:
+			return false
 		}
-		wg.Done()
+	}
+}
+
+// forwardDatagram sends a packet of a UDP session whole, or drops it if the client has no room for it.
+// It returns false if the session is closed.
+func (s *Service) forwardDatagram(ss *session, conn *connection, data []byte) bool {
+	if !conn.window.TakeAll(len(data)) {
+		s.stats.datagramsDropped.Add(1)
+		return true
+	}
+	return ss.pushDatagram(conn, data)
+}
+
+// tunnelStream is the server side of a Tunnel or Connect stream
+type tunnelStream interface {
+	Send(*tunnelv1.TunnelResponse) error
+	Recv() (*tunnelv1.TunnelRequest, error)
+}
+
+// serverStream is a Tunnel or Connect stream of either version of the API, speaking tunnel.v1 messages
+type serverStream interface {
+	tunnelStream
+	Context() context.Context
+}
+
+func (s *Service) Tunnel(stream tunnelv1.TunnelService_TunnelServer) error {
+	return s.tunnel(stream)
+}
+
+func (s *Service) tunnel(stream serverStream) error {
+	agreement, err := s.hello(stream)
+	if err != nil {
+		return err
+	}
+	caps := agreement.Capabilities
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	// clients that only dial serve no tunnel
+	if msg.Type != tunnelv1.RequestType_REGISTER || (len(msg.Tunnels) == 0 && !caps.Has(protocol.Dial)) {
+		return status.Error(codes.FailedPrecondition, "first message must register at least one tunnel")
+	}
+
+	identity := auth.IdentityFromContext(stream.Context())
+	if err := s.permissions.Authorize(identity, msg.Tunnels); err != nil {
+		s.log.Warn("Rejected registration", zap.String("identity", identity), zap.Error(err))
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	var ss *session
+	if msg.SessionId != "" && caps.Has(protocol.Resume) {
+		ss = s.resume(msg.SessionId, identity)
+	}
+	resumed := ss != nil
+	if resumed {
+		// the previous stream must be done with the session before this one serves it
+		ss.streams.Wait()
+		// the tunnels are those just authorized, the connections already opened are kept
+		ss.mu.Lock()
+		ss.tunnels = msg.Tunnels
+		ss.mu.Unlock()
+	} else {
+		ss = newSession(msg.Tunnels, int(msg.Weight), int(msg.Window))
+		ss.identity = identity
+		ss.dedicated = s.streamPerConnection && msg.StreamPerConnection && caps.Has(protocol.StreamPerConnection)
+		if caps.Has(protocol.Pool) {
+			ss.pool = msg.Pool
+		}
+	}
+	ss.capabilities = caps
+	grace := s.grace
+	if !caps.Has(protocol.Resume) {
+		grace = 0
+	}
+	log := s.log.With(zap.String("session", ss.id), zap.Strings("tunnels", ss.tunnels), zap.String("identity", identity))
+	registered := &tunnelv1.TunnelResponse{
+		Type:                tunnelv1.ResponseType_REGISTERED,
+		SessionId:           ss.id,
+		Window:              uint32(s.window),
+		StreamPerConnection: ss.dedicated,
+		Resumed:             resumed,
+		GracePeriod:         uint32(grace / time.Millisecond),
+	}
+	if err := stream.Send(registered); err != nil {
+		s.end(ss, err)
+		return err
+	}
+	s.register(ss)
+	if resumed {
+		s.resumeAll(ss)
+	}
+	log.Info("Client registered", zap.Bool("streamPerConnection", ss.dedicated), zap.Bool("resumed", resumed))
+
+	err = s.serve(ss, stream)
+	s.unregister(ss)
+	if s.end(ss, err) {
+		log.Info("Client disconnected", zap.Error(err))
+	} else {
+		log.Info("Client disconnected, holding its connections for it to resume", zap.Duration("gracePeriod", s.grace), zap.Error(err))
+	}
+	return err
+}
+
+// resumable reports whether the session can be resumed once its stream breaks
+func (s *Service) resumable(ss *session) bool {
+	return s.grace > 0 && ss.capabilities.Has(protocol.Resume)
+}
+
+// end ends the session of a stream that closed, or detaches it if the stream broke and sessions can be resumed.
+// It returns true if the session ended.
+func (s *Service) end(ss *session, err error) bool {
+	if err == nil || !s.resumable(ss) {
+		close(ss.done)
+		return true
+	}
+	s.detach(ss)
+	return false
+}
+
+// resumeAll tells the client what each connection of a resumed session received.
+// UDP sessions are closed instead, the packets lost with the stream are not sent again and the next packet of
+// their source opens a new one.
+func (s *Service) resumeAll(ss *session) {
+	ss.mu.Lock()
+	connections := make([]*connection, 0, len(ss.connections))
+	for _, conn := range ss.connections {
+		if conn.network == tunnelv1.Network_UDP {
+			conn.cancel()
+			continue
+		}
+		// connections on their own stream are not resumed
+		if conn.attached == nil {
+			connections = append(connections, conn)
+		}
+	}
+	ss.mu.Unlock()
+	for _, conn := range connections {
+		ss.pushResume(conn)
+	}
+}
+
+// serve exchanges the frames of a session with the client until the stream ends
+func (s *Service) serve(ss *session, stream tunnelStream) error {
+	errc := make(chan error, 3)
+	stop := make(chan struct{})
+	monitor := heartbeat.NewMonitor(s.heartbeat)
+	ss.mu.Lock()
+	ss.monitor = monitor
+	ss.mu.Unlock()
+	ss.streams.Add(3)
+	go func() {
+		defer ss.streams.Done()
+		errc <- s.receive(ss, stream)
 	}()
 	go func() {
-		for {
-			frame := <-s.output
-			var rt tunnelv1.ResponseType
-			switch frame.action {
-			case action_open:
-				rt = tunnelv1.ResponseType_OPEN_CONNECTION
-			case action_close:
-				rt = tunnelv1.ResponseType_CLOSE_CONNECTION
-			case action_data:
-				rt = tunnelv1.ResponseType_DATA_RECEIVE
+		defer ss.streams.Done()
+		errc <- s.send(ss, stream, stop)
+	}()
+	go func() {
+		defer ss.streams.Done()
+		// the stream of a single connection ends with it
+		if ss.single || !ss.capabilities.Has(protocol.Heartbeat) {
+			return
+		}
+		err := monitor.Run(stop, func(ts int64) {
+			ss.push(heartbeatPriority, frame{timestamp: ts, action: action_ping})
+		})
+		if err != nil {
+			s.log.Warn("Client stopped answering heartbeats", zap.String("session", ss.id), zap.Duration("rtt", monitor.RTT()))
+			errc <- err
+		}
+	}()
+	err := <-errc
+	close(stop)
+	return err
+}
+
+func (s *Service) receive(ss *session, stream tunnelStream) error {
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			s.log.Error("Failed to read from stream", zap.Error(err))
+			return err
+		}
+		switch msg.Type {
+		case tunnelv1.RequestType_PING:
+			ss.push(heartbeatPriority, frame{timestamp: msg.Timestamp, action: action_pong})
+			continue
+		case tunnelv1.RequestType_PONG:
+			rtt := ss.rtt(msg.Timestamp)
+			s.log.Debug("Heartbeat", zap.String("session", ss.id), zap.Duration("rtt", rtt))
+			continue
+		case tunnelv1.RequestType_DIAL:
+			go s.dial(ss, msg.DialId, msg.Destination)
+			continue
+		}
+		conn, ok := ss.connection(msg.ConnectionId)
+		if !ok && msg.Type == tunnelv1.RequestType_RESUME {
+			// the connection was closed while the client was away
+			ss.push(0, frame{id: msg.ConnectionId, action: action_close})
+			continue
+		}
+		if !ok {
+			s.log.Debug("Dropping message for unknown connection", zap.String("connectionId", msg.ConnectionId), zap.Stringer("type", msg.Type))
+			continue
+		}
+		switch msg.Type {
+		case tunnelv1.RequestType_OPEN_ACK:
+			s.stats.opened.Add(1)
+			s.log.Debug("Connection opened", zap.String("connectionId", msg.ConnectionId))
+			conn.ack()
+		case tunnelv1.RequestType_OPEN_FAILED:
+			s.stats.openFailed.Add(1)
+			s.log.Warn("Client cannot reach target", zap.String("connectionId", msg.ConnectionId), zap.String("reason", msg.Reason))
+			conn.fail(failure{reason: msg.Reason, kind: msg.Failure})
+		case tunnelv1.RequestType_CLOSE:
+			s.log.Debug("Connection closed by client", zap.String("connectionId", msg.ConnectionId))
+			conn.cancel()
+		case tunnelv1.RequestType_CLOSE_WRITE:
+			conn.output.CloseAt(int64(msg.Seq))
+		case tunnelv1.RequestType_WINDOW_UPDATE:
+			conn.window.Add(int(msg.Window))
+			if conn.replay != nil {
+				conn.replay.Ack(int(msg.Window))
 			}
-			err := stream.Send(&tunnelv1.TunnelResponse{ConnectionId: frame.id, Data: frame.data, Type: rt})
+		case tunnelv1.RequestType_RESUME:
+			s.log.Debug("Resuming connection", zap.String("connectionId", msg.ConnectionId), zap.Uint64("seq", msg.Seq))
+			ss.replay(conn, int64(msg.Seq), int64(msg.Ack))
+		case tunnelv1.RequestType_DATAGRAM:
+			// the packet is queued whole, the credit of the client keeps it within the window
+			if err := conn.output.Push(msg.Data); err != nil {
+				s.log.Warn("Closing connection", zap.String("connectionId", msg.ConnectionId), zap.Error(err))
+				conn.cancel()
+			}
+		default:
+			data, err := s.decode(msg)
 			if err != nil {
-				s.log.Error("Failed to write to stream", zap.Error(err))
-				break
+				s.log.Warn("Closing connection, cannot decompress data", zap.String("connectionId", msg.ConnectionId), zap.Error(err))
+				conn.cancel()
+				continue
+			}
+			// data is queued without waiting so a slow public connection does not stall the others
+			if err := conn.output.PushAt(int64(msg.Seq), data); err != nil {
+				s.log.Warn("Closing connection", zap.String("connectionId", msg.ConnectionId), zap.Error(err))
+				conn.cancel()
 			}
 		}
-		wg.Done()
-	}()
-	wg.Wait()
-	return nil
+	}
+}
+
+// decode returns the data sent by the client, decompressed
+func (s *Service) decode(msg *tunnelv1.TunnelRequest) ([]byte, error) {
+	if msg.Compression == tunnelv1.Compression_NONE {
+		return msg.Data, nil
+	}
+	alg, err := compress.FromProto(msg.Compression)
+	if err != nil {
+		return nil, err
+	}
+	// the data of a frame never exceeds the window granted to the client
+	data, err := compress.Decode(alg, msg.Data, s.window)
+	if err != nil {
+		return nil, err
+	}
+	s.stats.compressedReceived.Add(len(data), len(msg.Data))
+	return data, nil
+}
+
+func (s *Service) send(ss *session, stream tunnelStream, stop <-chan struct{}) error {
+	for {
+		frame, ok := ss.output.Pop(stop)
+		if !ok {
+			return nil
+		}
+		var rt tunnelv1.ResponseType
+		switch frame.action {
+		case action_open:
+			rt = tunnelv1.ResponseType_OPEN_CONNECTION
+		case action_close:
+			rt = tunnelv1.ResponseType_CLOSE_CONNECTION
+		case action_close_write:
+			rt = tunnelv1.ResponseType_CLOSE_WRITE_CONNECTION
+		case action_data:
+			rt = tunnelv1.ResponseType_DATA_RECEIVE
+		case action_window:
+			rt = tunnelv1.ResponseType_WINDOW_UPDATE_CONNECTION
+		case action_resume:
+			rt = tunnelv1.ResponseType_RESUME_CONNECTION
+		case action_ping:
+			rt = tunnelv1.ResponseType_HEARTBEAT_PING
+		case action_pong:
+			rt = tunnelv1.ResponseType_HEARTBEAT_PONG
+		case action_datagram:
+			rt = tunnelv1.ResponseType_DATAGRAM_RECEIVE
+		case action_dial_failed:
+			rt = tunnelv1.ResponseType_DIAL_FAILED
+		}
+		err := stream.Send(&tunnelv1.TunnelResponse{
+			ConnectionId: frame.id,
+			Tunnel:       frame.tunnel,
+			Data:         frame.data,
+			Window:       uint32(frame.window),
+			Seq:          uint64(frame.seq),
+			Ack:          uint64(frame.ack),
+			Timestamp:    frame.timestamp,
+			Compression:  frame.compression.Proto(),
+			Info:         frame.info,
+			Network:      frame.network,
+			DialId:       frame.dial,
+			Reason:       frame.reason,
+			Destination:  frame.destination,
+			Type:         rt,
+		})
+		if err != nil {
+			s.log.Error("Failed to write to stream", zap.Error(err))
+			return err
+		}
+		if ss.single && frame.action == action_close {
+			return nil
+		}
+	}
 }
@@ -0,0 +1,291 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/costap/tunnelv2/internal/pkg/allow"
+	"github.com/costap/tunnelv2/internal/pkg/heartbeat"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"github.com/costap/tunnelv2/internal/pkg/server/auth"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestService_PickPool(t *testing.T) {
+	s := NewService(zap.NewNop())
+	pooled := []*session{newSession([]string{"a"}, 1, 0), newSession([]string{"a"}, 1, 0)}
+	for _, ss := range pooled {
+		ss.identity, ss.pool = "client", "p"
+		s.register(ss)
+	}
+	single := newSession([]string{"a"}, 1, 0)
+	s.register(single)
+
+	picks := map[*session]int{}
+	for i := 0; i < 100; i++ {
+		picks[s.pick("a", fmt.Sprint("connection-", i))]++
+	}
+	// clients get the same share whatever the number of their streams
+	if n := picks[single]; n != 50 {
+		t.Fatalf("expected half of the connections for the single stream client, got %d", n)
+	}
+	for i, ss := range pooled {
+		if picks[ss] < 10 {
+			t.Fatalf("expected connections spread across the pool, stream %d got %d", i, picks[ss])
+		}
+	}
+	s.unregister(single)
+	if s.pick("a", "x") != s.pick("a", "x") {
+		t.Fatal("expected a connection id to hash to the same stream of a pool")
+	}
+
+	pooled[0].add(&connection{id: "1"})
+	if n := pooled[1].active(); n != 1 {
+		t.Fatalf("expected the streams of a pool to share their load, got %d", n)
+	}
+}
+
+func TestService_Resume(t *testing.T) {
+	s := NewService(zap.NewNop(), WithGracePeriod(20*time.Millisecond))
+	ss := newSession([]string{"a"}, 1, 0)
+	ss.identity = "client"
+	s.detach(ss)
+	if s.resume(ss.id, "other") != nil {
+		t.Fatal("expected a session not to be resumed by another identity")
+	}
+	if s.resume(ss.id, "client") != ss {
+		t.Fatal("expected the session to be resumed")
+	}
+	if s.resume(ss.id, "client") != nil {
+		t.Fatal("expected a resumed session not to be resumed again")
+	}
+
+	s.detach(ss)
+	select {
+	case <-ss.done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the session to end after the grace period")
+	}
+	if s.resume(ss.id, "client") != nil {
+		t.Fatal("expected an expired session not to be resumed")
+	}
+}
+
+func TestService_ReplayOnlyIfResumable(t *testing.T) {
+	tests := []struct {
+		grace        time.Duration
+		capabilities protocol.Capabilities
+		network      tunnelv1.Network
+		replay       bool
+	}{
+		{time.Second, protocol.Supported, tunnelv1.Network_TCP, true},
+		{0, protocol.Supported, tunnelv1.Network_TCP, false},
+		{time.Second, protocol.NewCapabilities(protocol.FlowControl, protocol.HalfClose), tunnelv1.Network_TCP, false},
+		{time.Second, protocol.Supported, tunnelv1.Network_UDP, false},
+	}
+	for i, tt := range tests {
+		s := NewService(zap.NewNop(), WithGracePeriod(tt.grace))
+		ss := newSession([]string{"a"}, 1, 0)
+		ss.capabilities = tt.capabilities
+		ctx, cancel := context.WithCancel(context.Background())
+		conn := &connection{id: fmt.Sprint(i), network: tt.network, input: make(chan []byte), failed: make(chan failure, 1), cancel: cancel}
+		if err := s.open(ctx, ss, "a", conn); err != nil {
+			t.Fatal(err)
+		}
+		if (conn.replay != nil) != tt.replay {
+			t.Errorf("%d: expected data kept for resume %v, got %v", i, tt.replay, conn.replay != nil)
+		}
+		// the offsets do not depend on the data being kept
+		ss.pushData(conn, []byte("abc"))
+		ss.pushCloseWrite(conn)
+		if conn.sent != 3 {
+			t.Errorf("%d: expected the data to end at 3, got %d", i, conn.sent)
+		}
+		cancel()
+		close(ss.done)
+	}
+}
+
+// silentStream is a client stream that never sends anything
+type silentStream struct {
+	done chan struct{}
+}
+
+func (silentStream) Send(*tunnelv1.TunnelResponse) error {
+	return nil
+}
+
+func (s silentStream) Recv() (*tunnelv1.TunnelRequest, error) {
+	<-s.done
+	return nil, errors.New("stream closed")
+}
+
+func TestService_HeartbeatDeadClient(t *testing.T) {
+	s := NewService(zap.NewNop(), WithHeartbeat(heartbeat.Config{Interval: 10 * time.Millisecond, Misses: 2}))
+	stream := silentStream{done: make(chan struct{})}
+	defer close(stream.done)
+	ss := newSession([]string{"a"}, 1, 0)
+	ss.capabilities = protocol.Supported
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.serve(ss, stream)
+	}()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, heartbeat.ErrPeerDead) {
+			t.Fatalf("expected %v, got %v", heartbeat.ErrPeerDead, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to be torn down")
+	}
+}
+
+// scriptedStream is a client stream sending the given requests
+type scriptedStream struct {
+	in  []*tunnelv1.TunnelRequest
+	out []*tunnelv1.TunnelResponse
+}
+
+func (s *scriptedStream) Send(resp *tunnelv1.TunnelResponse) error {
+	s.out = append(s.out, resp)
+	return nil
+}
+
+func (s *scriptedStream) Recv() (*tunnelv1.TunnelRequest, error) {
+	if len(s.in) == 0 {
+		return nil, errors.New("stream closed")
+	}
+	req := s.in[0]
+	s.in = s.in[1:]
+	return req, nil
+}
+
+func TestSession_ControlQueues(t *testing.T) {
+	ss := newSession([]string{"a"}, 1, 0)
+	// a failed dial queued first does not hold the heartbeats in its class
+	ss.push(0, frame{dial: 1, reason: "denied", action: action_dial_failed})
+	ss.push(0, frame{id: "1", data: make([]byte, 64<<10), action: action_data})
+	ss.push(heartbeatPriority, frame{timestamp: 42, action: action_ping})
+	if f, _ := ss.output.Pop(nil); f.action != action_ping {
+		t.Fatalf("expected the ping first, got action %d", f.action)
+	}
+}
+
+// heldStream is a scripted stream of a Tunnel call, kept open once its requests are sent until held is closed
+type heldStream struct {
+	*scriptedStream
+	held chan struct{}
+}
+
+func (s heldStream) Recv() (*tunnelv1.TunnelRequest, error) {
+	if len(s.in) == 0 {
+		<-s.held
+	}
+	return s.scriptedStream.Recv()
+}
+
+func (s heldStream) Context() context.Context {
+	return context.Background()
+}
+
+func TestService_ResumeTunnels(t *testing.T) {
+	s := NewService(zap.NewNop(), WithGracePeriod(time.Minute))
+	ss := newSession([]string{"a", "b"}, 1, 0)
+	ss.capabilities = protocol.NewCapabilities(protocol.Resume)
+	s.detach(ss)
+
+	caps := []string{protocol.FlowControl, protocol.HalfClose, protocol.Resume}
+	stream := heldStream{held: make(chan struct{}), scriptedStream: &scriptedStream{in: []*tunnelv1.TunnelRequest{
+		{Type: tunnelv1.RequestType_HELLO, Hello: &tunnelv1.Hello{Protocol: protocol.Version, Capabilities: caps}},
+		{Type: tunnelv1.RequestType_REGISTER, Tunnels: []string{"a"}, SessionId: ss.id},
+	}}}
+	done := make(chan error, 1)
+	go func() { done <- s.tunnel(stream) }()
+	for !s.serves("a") {
+		time.Sleep(time.Millisecond)
+	}
+	// the session serves the tunnels the client resumed it with, not those it had
+	if s.serves("b") {
+		t.Fatal("expected the tunnel left out of the resumed session not to be served")
+	}
+	close(stream.held)
+	<-done
+}
+
+func TestService_Hello(t *testing.T) {
+	s := NewService(zap.NewNop(), WithVersion("v1.2.3"))
+	hello := &tunnelv1.TunnelRequest{
+		Type:  tunnelv1.RequestType_HELLO,
+		Hello: &tunnelv1.Hello{Protocol: protocol.Version, Capabilities: []string{protocol.FlowControl, protocol.HalfClose, protocol.Resume}},
+	}
+	stream := &scriptedStream{in: []*tunnelv1.TunnelRequest{hello}}
+	agreement, err := s.hello(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agreement.Capabilities.Has(protocol.Heartbeat) || !agreement.Capabilities.Has(protocol.Resume) {
+		t.Fatalf("expected the capabilities of the client, got %v", agreement.Capabilities.List())
+	}
+	if ack := stream.out[0]; ack.Type != tunnelv1.ResponseType_HELLO_ACK || ack.Hello.Version != "v1.2.3" || len(ack.Hello.Capabilities) != 3 {
+		t.Fatalf("unexpected answer %v", ack)
+	}
+
+	for name, req := range map[string]*tunnelv1.TunnelRequest{
+		"no hello":           {Type: tunnelv1.RequestType_REGISTER, Tunnels: []string{"a"}},
+		"missing half-close": {Type: tunnelv1.RequestType_HELLO, Hello: &tunnelv1.Hello{Protocol: protocol.Version, Capabilities: []string{protocol.FlowControl}}},
+	} {
+		if _, err := s.hello(&scriptedStream{in: []*tunnelv1.TunnelRequest{req}}); status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("%s: expected the client to be refused, got %v", name, err)
+		}
+	}
+}
+
+func TestService_Dial(t *testing.T) {
+	hello := func() *scriptedStream {
+		return &scriptedStream{in: []*tunnelv1.TunnelRequest{{
+			Type:  tunnelv1.RequestType_HELLO,
+			Hello: &tunnelv1.Hello{Protocol: protocol.Version, Capabilities: []string{protocol.FlowControl, protocol.HalfClose, protocol.Dial}},
+		}}}
+	}
+	// without allowed destinations dialing is not advertised, nor done
+	s := NewService(zap.NewNop())
+	agreement, err := s.hello(hello())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agreement.Capabilities.Has(protocol.Dial) {
+		t.Fatal("expected dialing not to be agreed without allowed destinations")
+	}
+	ss := newSession(nil, 1, 0)
+	ss.capabilities = protocol.NewCapabilities(protocol.Dial)
+	if _, err := s.dialDestination(ss, "127.0.0.1:1"); !errors.Is(err, errDialDisabled) {
+		t.Fatalf("expected %v, got %v", errDialDisabled, err)
+	}
+
+	destinations, err := allow.Parse([]string{"127.0.0.1:*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = NewService(zap.NewNop(), WithDial(destinations), WithPermissions(auth.NewPermissions(map[string][]string{"team-a": {"web"}})))
+	if agreement, err = s.hello(hello()); err != nil {
+		t.Fatal(err)
+	}
+	if !agreement.Capabilities.Has(protocol.Dial) {
+		t.Fatal("expected dialing to be agreed")
+	}
+	// the identity of the client must be permitted to dial the destination
+	ss.identity = "cert:team-a"
+	if _, err := s.dialDestination(ss, "127.0.0.1:1"); !errors.Is(err, auth.ErrPermissionDenied) {
+		t.Fatalf("expected %v, got %v", auth.ErrPermissionDenied, err)
+	}
+	// nor is a client dialing without the capability
+	ss.capabilities = protocol.Capabilities{}
+	if _, err := s.dialDestination(ss, "127.0.0.1:1"); !errors.Is(err, errDialDisabled) {
+		t.Fatalf("expected %v, got %v", errDialDisabled, err)
+	}
+}
@@ -0,0 +1,68 @@
+package tunnel
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+// DefaultServerNameTimeout bounds the wait for the TLS client hello of a public peer
+const DefaultServerNameTimeout = 5 * time.Second
+
+// errHelloRead aborts the handshake once the client hello is read
+var errHelloRead = errors.New("client hello read")
+
+// peekServerName reads the TLS client hello a public peer starts with and returns the server name it asks for, along
+// with a connection reading again what was peeked. It fails if the peer does not start with a client hello in time.
+func peekServerName(conn net.Conn, timeout time.Duration) (string, net.Conn, error) {
+	var peeked bytes.Buffer
+	var name string
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	err := tls.Server(readOnlyConn{Conn: conn, r: io.TeeReader(conn, &peeked)}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			name = hello.ServerName
+			return nil, errHelloRead
+		},
+	}).Handshake()
+	conn.SetReadDeadline(time.Time{})
+	pc := &peekedConn{Conn: conn, r: io.MultiReader(&peeked, conn)}
+	if !errors.Is(err, errHelloRead) {
+		return "", pc, err
+	}
+	return name, pc, nil
+}
+
+// readOnlyConn keeps the handshake from writing to the peer
+type readOnlyConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c readOnlyConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (c readOnlyConn) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+// peekedConn reads again the data peeked before reading from the connection
+type peekedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// CloseWrite shuts down the write side of the connection if it supports it
+func (c *peekedConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
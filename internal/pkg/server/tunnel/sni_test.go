@@ -0,0 +1,65 @@
+package tunnel
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPeekServerName(t *testing.T) {
+	public, conn := net.Pipe()
+	defer public.Close()
+	defer conn.Close()
+	// the handshake stalls once the client hello is sent, capture what is sent until then
+	hello := make(chan []byte, 1)
+	go func() {
+		var sent bytes.Buffer
+		tls.Client(recordingConn{Conn: public, w: &sent}, &tls.Config{ServerName: "example.com"}).Handshake()
+		hello <- sent.Bytes()
+	}()
+
+	name, peeked, err := peekServerName(conn, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "example.com" {
+		t.Fatalf("expected server name example.com, got %q", name)
+	}
+	public.Close()
+	sent := <-hello
+	got, _ := io.ReadAll(peeked)
+	if !bytes.Equal(got, sent) {
+		t.Fatalf("expected the client hello to be read again, got %d of %d bytes", len(got), len(sent))
+	}
+}
+
+func TestPeekServerName_NotTLS(t *testing.T) {
+	public, conn := net.Pipe()
+	defer conn.Close()
+	go func() {
+		public.Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+		public.Close()
+	}()
+
+	name, peeked, err := peekServerName(conn, time.Second)
+	if err == nil || name != "" {
+		t.Fatalf("expected no server name, got %q %v", name, err)
+	}
+	if got, _ := io.ReadAll(peeked); string(got) != "GET / HTTP/1.1\r\n\r\n" {
+		t.Fatalf("expected the request to be read again, got %q", got)
+	}
+}
+
+// recordingConn records what is written to the connection
+type recordingConn struct {
+	net.Conn
+	w io.Writer
+}
+
+func (c recordingConn) Write(p []byte) (int, error) {
+	c.w.Write(p)
+	return c.Conn.Write(p)
+}
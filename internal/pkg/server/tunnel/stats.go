@@ -0,0 +1,160 @@
+package tunnel
+
+import (
+	"github.com/costap/tunnelv2/internal/pkg/compress"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are counters of the connections tunnelled by a Service
+type Stats struct {
+	// Accepted public connections handed to a client
+	Accepted uint64
+	// Rejected public connections without a client serving their tunnel
+	Rejected uint64
+	// Opened connections whose target the client reached
+	Opened uint64
+	// OpenFailed connections whose target the client could not reach
+	OpenFailed uint64
+	// CompressedSent is the data compressed for clients, CompressedReceived the data clients compressed
+	CompressedSent     CompressionStats
+	CompressedReceived CompressionStats
+	// Dialed destinations clients asked for, DialFailed those not allowed or unreachable
+	Dialed     uint64
+	DialFailed uint64
+	// DatagramsDropped are packets of UDP sessions dropped because the client had no room for them
+	DatagramsDropped uint64
+	// Clients are the registered client streams
+	Clients []ClientStats
+}
+
+func (s Stats) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint64("accepted", s.Accepted)
+	enc.AddUint64("rejected", s.Rejected)
+	enc.AddUint64("opened", s.Opened)
+	enc.AddUint64("openFailed", s.OpenFailed)
+	enc.AddUint64("dialed", s.Dialed)
+	enc.AddUint64("dialFailed", s.DialFailed)
+	enc.AddUint64("datagramsDropped", s.DatagramsDropped)
+	if err := enc.AddObject("compressedSent", s.CompressedSent); err != nil {
+		return err
+	}
+	if err := enc.AddObject("compressedReceived", s.CompressedReceived); err != nil {
+		return err
+	}
+	return enc.AddArray("clients", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, c := range s.Clients {
+			if err := enc.AppendObject(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// CompressionStats are the bytes of the data compressed, before and after compression
+type CompressionStats struct {
+	Raw        uint64
+	Compressed uint64
+}
+
+// Ratio returns the size of the data compressed relative to its raw size, 1 if nothing was compressed
+func (c CompressionStats) Ratio() float64 {
+	if c.Raw == 0 {
+		return 1
+	}
+	return float64(c.Compressed) / float64(c.Raw)
+}
+
+func (c CompressionStats) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint64("raw", c.Raw)
+	enc.AddUint64("compressed", c.Compressed)
+	enc.AddFloat64("ratio", c.Ratio())
+	return nil
+}
+
+// ClientStats describe a client stream registered on a Service
+type ClientStats struct {
+	Session  string
+	Identity string
+	Tunnels  []string
+	// Connections open on the stream
+	Connections int
+	// RTT is the round-trip time of the last heartbeat answered by the client, 0 until one is
+	RTT time.Duration
+}
+
+func (c ClientStats) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("session", c.Session)
+	enc.AddString("identity", c.Identity)
+	if err := enc.AddArray("tunnels", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, t := range c.Tunnels {
+			enc.AppendString(t)
+		}
+		return nil
+	})); err != nil {
+		return err
+	}
+	enc.AddInt("connections", c.Connections)
+	enc.AddDuration("rtt", c.RTT)
+	return nil
+}
+
+type stats struct {
+	accepted, rejected, opened, openFailed atomic.Uint64
+	dialed, dialFailed, datagramsDropped   atomic.Uint64
+	compressedSent, compressedReceived     compress.Counter
+}
+
+func compressionStats(c *compress.Counter) CompressionStats {
+	raw, compressed := c.Load()
+	return CompressionStats{Raw: raw, Compressed: compressed}
+}
+
+// Stats returns a snapshot of the service counters
+func (s *Service) Stats() Stats {
+	return Stats{
+		Accepted:           s.stats.accepted.Load(),
+		Rejected:           s.stats.rejected.Load(),
+		Opened:             s.stats.opened.Load(),
+		OpenFailed:         s.stats.openFailed.Load(),
+		CompressedSent:     compressionStats(&s.stats.compressedSent),
+		CompressedReceived: compressionStats(&s.stats.compressedReceived),
+		Dialed:             s.stats.dialed.Load(),
+		DialFailed:         s.stats.dialFailed.Load(),
+		DatagramsDropped:   s.stats.datagramsDropped.Load(),
+		Clients:            s.clientStats(),
+	}
+}
+
+func (s *Service) clientStats() []ClientStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(map[*session]bool)
+	var clients []ClientStats
+	for _, sessions := range s.sessions {
+		for _, ss := range sessions {
+			if seen[ss] {
+				continue
+			}
+			seen[ss] = true
+			ss.mu.Lock()
+			c := ClientStats{Session: ss.id, Identity: ss.identity, Tunnels: ss.tunnels, Connections: len(ss.connections)}
+			if ss.monitor != nil {
+				c.RTT = ss.monitor.RTT()
+			}
+			ss.mu.Unlock()
+			clients = append(clients, c)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Session < clients[j].Session })
+	return clients
+}
+
+// LogStats logs a snapshot of the service counters
+func (s *Service) LogStats() {
+	s.log.Info("Tunnel stats", zap.Object("stats", s.Stats()))
+}
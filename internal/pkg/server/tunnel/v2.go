@@ -0,0 +1,61 @@
+package tunnel
+
+import (
+	"context"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v1"
+	"github.com/costap/tunnelv2/internal/pkg/proto/tunnel/v2"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceV2 serves the tunnel.v2 API of a Service, so that clients of both versions share its tunnels
+type ServiceV2 struct {
+	s *Service
+}
+
+func NewServiceV2(service *Service) *ServiceV2 {
+	return &ServiceV2{s: service}
+}
+
+func (v *ServiceV2) Tunnel(stream tunnelv2.TunnelService_TunnelServer) error {
+	return v.s.tunnel(streamV2{stream})
+}
+
+// Connect carries a connection opened on a dedicated session on its own stream
+func (v *ServiceV2) Connect(stream tunnelv2.TunnelService_ConnectServer) error {
+	return v.s.connect(streamV2{stream})
+}
+
+// streamV2 translates the messages of a tunnel.v2 stream
+type streamV2 struct {
+	stream interface {
+		Send(*tunnelv2.TunnelResponse) error
+		Recv() (*tunnelv2.TunnelRequest, error)
+		Context() context.Context
+	}
+}
+
+func (s streamV2) Send(resp *tunnelv1.TunnelResponse) error {
+	msg, err := protocol.ResponseToV2(resp)
+	if err != nil {
+		return err
+	}
+	return s.stream.Send(msg)
+}
+
+func (s streamV2) Recv() (*tunnelv1.TunnelRequest, error) {
+	msg, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	req, err := protocol.RequestFromV2(msg)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return req, nil
+}
+
+func (s streamV2) Context() context.Context {
+	return s.stream.Context()
+}
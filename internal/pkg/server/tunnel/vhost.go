@@ -0,0 +1,227 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"html/template"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultErrorPage answers the requests for hosts no client serves, or whose client cannot reach its target
+var DefaultErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Status}} {{.StatusText}}</title></head>
+<body>
+<h1>{{.Status}} {{.StatusText}}</h1>
+<p>{{if eq .Status 404}}No tunnel serves {{.Host}}.{{else}}The tunnel of {{.Host}} cannot reach its target.{{end}}</p>
+</body>
+</html>
+`))
+
+// errNoTunnel is returned when dialing for a host no client serves
+var errNoTunnel = errors.New("no tunnel serves the host")
+
+// ErrorPageData is given to the error page template
+type ErrorPageData struct {
+	Status     int
+	StatusText string
+	Host       string
+}
+
+// VhostController routes the HTTP requests of public peers to the tunnel named after their Host, or after the wildcard
+// of its parent such as *.example.com for api.example.com but not a.b.example.com. Each request of a keep-alive
+// connection is routed on its own.
+type VhostController struct {
+	log  *zap.Logger
+	s    *Service
+	page *template.Template
+}
+
+// VhostOption configures a VhostController
+type VhostOption func(*VhostController)
+
+// WithErrorPage sets the template of the page answering requests with 404 or 502, executed with an ErrorPageData
+func WithErrorPage(page *template.Template) VhostOption {
+	return func(c *VhostController) {
+		c.page = page
+	}
+}
+
+func NewVhostController(log *zap.Logger, service *Service, opts ...VhostOption) *VhostController {
+	c := &VhostController{log: log, s: service, page: DefaultErrorPage}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *VhostController) Handle(ctx context.Context, conn net.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the connections to the targets are kept alive for the next requests of the peer only
+	transport := &http.Transport{
+		DialContext: func(_ context.Context, _, addr string) (net.Conn, error) {
+			// the client of the host may have left since the request was routed
+			tunnel := c.route(hostname(addr))
+			if tunnel == "" {
+				return nil, fmt.Errorf("%w: %s", errNoTunnel, addr)
+			}
+			return c.dial(ctx, conn, tunnel), nil
+		},
+		IdleConnTimeout: time.Minute,
+	}
+	defer transport.CloseIdleConnections()
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = req.Host
+			req.Header.Set("X-Forwarded-Host", req.Host)
+			req.Header.Set("X-Forwarded-Proto", "http")
+		},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			if errors.Is(err, errNoTunnel) {
+				c.errorPage(w, req, http.StatusNotFound)
+				return
+			}
+			c.log.Info("Cannot reach the target of the host", zap.String("host", req.Host), zap.Error(err))
+			c.errorPage(w, req, http.StatusBadGateway)
+		},
+		ErrorLog: zap.NewStdLog(c.log),
+	}
+
+	closed := make(chan struct{})
+	var closeOnce sync.Once
+	// closing the server does not wait for the requests it is serving, nor are requests started once it is stopped
+	var handlers sync.WaitGroup
+	var mu sync.Mutex
+	stopped := false
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			if stopped {
+				mu.Unlock()
+				return
+			}
+			handlers.Add(1)
+			mu.Unlock()
+			defer handlers.Done()
+			if c.route(hostname(req.Host)) == "" {
+				c.log.Debug("No tunnel serves the host", zap.String("host", req.Host), zap.String("remoteAddress", req.RemoteAddr))
+				c.errorPage(w, req, http.StatusNotFound)
+				return
+			}
+			proxy.ServeHTTP(w, req)
+		}),
+		ReadHeaderTimeout: handshakeTimeout,
+		ConnState: func(_ net.Conn, state http.ConnState) {
+			// a hijacked connection is upgraded, its handler copies the data until either side closes it
+			if state == http.StateClosed || state == http.StateHijacked {
+				closeOnce.Do(func() { close(closed) })
+			}
+		},
+		ErrorLog: zap.NewStdLog(c.log),
+	}
+	l := &connListener{conn: conn, closed: make(chan struct{})}
+	go srv.Serve(l)
+	select {
+	case <-closed:
+	case <-ctx.Done():
+	}
+	srv.Close()
+	mu.Lock()
+	stopped = true
+	mu.Unlock()
+	handlers.Wait()
+}
+
+// route returns the tunnel serving host, empty if none does
+func (c *VhostController) route(host string) string {
+	if host == "" {
+		return ""
+	}
+	if c.s.serves(host) {
+		return host
+	}
+	// a wildcard covers a single label, as in certificates
+	if _, parent, ok := strings.Cut(host, "."); ok {
+		if wildcard := "*." + parent; c.s.serves(wildcard) {
+			return wildcard
+		}
+	}
+	return ""
+}
+
+// dial opens a connection of the tunnel for the requests of the public peer, through the controller of the tunnel
+func (c *VhostController) dial(ctx context.Context, peer net.Conn, tunnel string) net.Conn {
+	client, server := net.Pipe()
+	controller := NewController(c.log.With(zap.String("tunnel", tunnel)), c.s, tunnel)
+	go controller.Handle(ctx, &addrConn{Conn: server, local: peer.LocalAddr(), remote: peer.RemoteAddr()})
+	return client
+}
+
+// errorPage answers a request with the error page
+func (c *VhostController) errorPage(w http.ResponseWriter, req *http.Request, status int) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := c.page.Execute(w, ErrorPageData{Status: status, StatusText: http.StatusText(status), Host: req.Host}); err != nil {
+		c.log.Warn("Cannot write error page", zap.Error(err))
+	}
+}
+
+// hostname returns the name of a Host header, lower case and without port
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// connListener accepts a single connection
+type connListener struct {
+	conn      net.Conn
+	once      sync.Once
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() { conn = l.conn })
+	if conn != nil {
+		return conn, nil
+	}
+	<-l.closed
+	return nil, net.ErrClosed
+}
+
+func (l *connListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// addrConn gives the connection of a request the addresses of the public peer
+type addrConn struct {
+	net.Conn
+	local, remote net.Addr
+}
+
+func (c *addrConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *addrConn) RemoteAddr() net.Addr {
+	return c.remote
+}
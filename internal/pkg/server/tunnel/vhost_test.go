@@ -0,0 +1,72 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestVhostController_Route(t *testing.T) {
+	s := NewService(zap.NewNop())
+	s.register(newSession([]string{"app.example.com", "*.example.com", "*.dev.example.com"}, 1, 0))
+	c := NewVhostController(zap.NewNop(), s)
+
+	tests := []struct {
+		host   string
+		tunnel string
+	}{
+		{"app.example.com", "app.example.com"},
+		{"APP.example.com:8080", "app.example.com"},
+		{"app.example.com.", "app.example.com"},
+		{"api.example.com", "*.example.com"},
+		{"a.b.example.com", ""},
+		{"api.dev.example.com", "*.dev.example.com"},
+		{"example.com", ""},
+		{"example.org", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := c.route(hostname(tt.host)); got != tt.tunnel {
+			t.Errorf("expected %q to route to %q, got %q", tt.host, tt.tunnel, got)
+		}
+	}
+}
+
+func TestVhostController_Close(t *testing.T) {
+	c := NewVhostController(zap.NewNop(), NewService(zap.NewNop()))
+	for i := 0; i < 20; i++ {
+		peer, conn := net.Pipe()
+		ctx, cancel := context.WithCancel(context.Background())
+		handled := make(chan struct{})
+		go func() {
+			c.Handle(ctx, conn)
+			close(handled)
+		}()
+		go func() {
+			// requests keep coming while the controller is stopped
+			r := bufio.NewReader(peer)
+			for {
+				if _, err := peer.Write([]byte("GET / HTTP/1.1\r\nHost: app.example.com\r\n\r\n")); err != nil {
+					return
+				}
+				resp, err := http.ReadResponse(r, nil)
+				if err != nil {
+					return
+				}
+				io.Copy(io.Discard, resp.Body)
+				if resp.StatusCode != http.StatusNotFound {
+					t.Errorf("expected host without tunnel to be not found, got %d", resp.StatusCode)
+				}
+			}
+		}()
+		time.Sleep(time.Millisecond)
+		cancel()
+		<-handled
+		peer.Close()
+	}
+}
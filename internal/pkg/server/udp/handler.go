@@ -0,0 +1,10 @@
+package udp
+
+import (
+	"context"
+	"net"
+)
+
+type Handler interface {
+	Handle(ctx context.Context, conn net.Conn)
+}
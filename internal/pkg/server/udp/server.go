@@ -0,0 +1,178 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultIdleTimeout is the time after which a session without packets in either direction is closed
+const DefaultIdleTimeout = time.Minute
+
+// queueSize bounds the packets of a session waiting to be read, later ones are dropped
+const queueSize = 64
+
+// errClosed is returned by the sessions once closed
+var errClosed = errors.New("session closed")
+
+// Server reads the packets of a UDP socket and hands those of each source address to the handler as a session,
+// a net.Conn whose reads and writes are single packets. Sessions are closed once idle.
+type Server struct {
+	idle time.Duration
+
+	mu       sync.Mutex
+	pc       net.PacketConn
+	sessions map[string]*session
+}
+
+func NewServer(idle time.Duration) *Server {
+	if idle <= 0 {
+		idle = DefaultIdleTimeout
+	}
+	return &Server{idle: idle, sessions: make(map[string]*session)}
+}
+
+func (s *Server) Serve(pc net.PacketConn, handler Handler) error {
+	s.mu.Lock()
+	s.pc = pc
+	s.mu.Unlock()
+
+	go func() {
+		buf := make([]byte, protocol.MaxDatagramSize)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			ss, created := s.session(pc, addr)
+			if created {
+				go handler.Handle(context.Background(), ss)
+			}
+			// the packet is copied out of the read buffer at its own size
+			ss.push(append([]byte(nil), buf[:n]...))
+		}
+	}()
+	return nil
+}
+
+// session returns the session of a source address, creating it if needed
+func (s *Server) session(pc net.PacketConn, addr net.Addr) (*session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ss, ok := s.sessions[addr.String()]; ok {
+		return ss, false
+	}
+	ss := &session{s: s, pc: pc, remote: addr, in: make(chan []byte, queueSize), done: make(chan struct{})}
+	ss.timer = time.AfterFunc(s.idle, func() { ss.Close() })
+	s.sessions[addr.String()] = ss
+	return ss, true
+}
+
+func (s *Server) remove(ss *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions[ss.remote.String()] == ss {
+		delete(s.sessions, ss.remote.String())
+	}
+}
+
+// Sessions returns the number of open sessions
+func (s *Server) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// Close closes the socket and the open sessions
+func (s *Server) Close() error {
+	s.mu.Lock()
+	pc := s.pc
+	sessions := make([]*session, 0, len(s.sessions))
+	for _, ss := range s.sessions {
+		sessions = append(sessions, ss)
+	}
+	s.mu.Unlock()
+	for _, ss := range sessions {
+		ss.Close()
+	}
+	if pc == nil {
+		return nil
+	}
+	return pc.Close()
+}
+
+// session is the packets exchanged with a source address
+type session struct {
+	s      *Server
+	pc     net.PacketConn
+	remote net.Addr
+	in     chan []byte
+	timer  *time.Timer
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// push queues a packet of the source without waiting, dropping it if the reader lags behind
+func (ss *session) push(data []byte) {
+	ss.timer.Reset(ss.s.idle)
+	select {
+	case ss.in <- data:
+	case <-ss.done:
+	default:
+	}
+}
+
+// Read reads the next packet, truncated to b like a read on a UDP socket
+func (ss *session) Read(b []byte) (int, error) {
+	select {
+	case data := <-ss.in:
+		return copy(b, data), nil
+	case <-ss.done:
+		return 0, errClosed
+	}
+}
+
+// Write sends b to the source as a single packet
+func (ss *session) Write(b []byte) (int, error) {
+	select {
+	case <-ss.done:
+		return 0, errClosed
+	default:
+	}
+	ss.timer.Reset(ss.s.idle)
+	return ss.pc.WriteTo(b, ss.remote)
+}
+
+func (ss *session) Close() error {
+	ss.closeOnce.Do(func() {
+		ss.timer.Stop()
+		close(ss.done)
+		ss.s.remove(ss)
+	})
+	return nil
+}
+
+func (ss *session) LocalAddr() net.Addr {
+	return ss.pc.LocalAddr()
+}
+
+func (ss *session) RemoteAddr() net.Addr {
+	return ss.remote
+}
+
+// SetDeadline is not supported, sessions are closed once idle instead
+func (ss *session) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (ss *session) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (ss *session) SetWriteDeadline(time.Time) error {
+	return nil
+}
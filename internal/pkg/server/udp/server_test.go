@@ -0,0 +1,110 @@
+package udp
+
+import (
+	"context"
+	"github.com/costap/tunnelv2/internal/pkg/protocol"
+	"net"
+	"testing"
+	"time"
+)
+
+// echo replies to each packet of a session with the packet and the number of packets of the session so far
+type echo struct {
+	sessions chan net.Conn
+}
+
+func (e echo) Handle(ctx context.Context, conn net.Conn) {
+	e.sessions <- conn
+	buf := make([]byte, protocol.MaxDatagramSize)
+	for i := byte(1); ; i++ {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		conn.Write(append(buf[:n:n], i))
+	}
+}
+
+func listen(t *testing.T) net.PacketConn {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pc
+}
+
+func exchange(t *testing.T, conn net.Conn, packet string) string {
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Write([]byte(packet)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestServer_Sessions(t *testing.T) {
+	s := NewServer(time.Minute)
+	e := echo{sessions: make(chan net.Conn, 2)}
+	pc := listen(t)
+	if err := s.Serve(pc, e); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	a, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if got := exchange(t, a, "one"); got != "one\x01" {
+		t.Fatalf("expected the first packet of a, got %q", got)
+	}
+	if got := exchange(t, a, "two"); got != "two\x02" {
+		t.Fatalf("expected the second packet of a, got %q", got)
+	}
+	if got := exchange(t, b, "one"); got != "one\x01" {
+		t.Fatalf("expected b in a session of its own, got %q", got)
+	}
+	if n := s.Sessions(); n != 2 {
+		t.Fatalf("expected 2 sessions, got %d", n)
+	}
+	if conn := <-e.sessions; conn.RemoteAddr().String() != a.LocalAddr().String() {
+		t.Fatalf("expected the session of %s, got %s", a.LocalAddr(), conn.RemoteAddr())
+	}
+}
+
+func TestServer_IdleTimeout(t *testing.T) {
+	s := NewServer(50 * time.Millisecond)
+	e := echo{sessions: make(chan net.Conn, 2)}
+	pc := listen(t)
+	if err := s.Serve(pc, e); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	a, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if got := exchange(t, a, "one"); got != "one\x01" {
+		t.Fatalf("expected the first packet, got %q", got)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := s.Sessions(); n != 0 {
+		t.Fatalf("expected the idle session to be closed, got %d sessions", n)
+	}
+	if got := exchange(t, a, "two"); got != "two\x01" {
+		t.Fatalf("expected a new session, got %q", got)
+	}
+}